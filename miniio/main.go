@@ -1,9 +1,15 @@
 package main
 
 import (
+	chunk_routes "ImageUploadMiniIo/pkg/image_chunks/routes"
+	"ImageUploadMiniIo/pkg/job_manager"
+	miniio "ImageUploadMiniIo/pkg/mini_io"
 	chunk_redis "ImageUploadMiniIo/pkg/redis"
+	"ImageUploadMiniIo/pkg/session_store"
 	"log"
 	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
@@ -20,23 +26,40 @@ func main() {
 	// Getting the chunk api port.
 	chunkPort := os.Getenv("CHUNK_PORT")
 
-	// Declaring a gin server.
+	// Creating the object store up front, so that a misconfigured one stops the application straight away.
+	miniio.GetObjectStore()
+
+	// Starting the workers finalizing the uploads, before the routes enqueueing their jobs are served.
+	job_manager.Start()
+
+	// Declaring a gin server and registering the routes.
 	chunkRouter := gin.New()
+	chunk_routes.ChunkRoutes(chunkRouter)
+	chunk_routes.TusRoutes(chunkRouter)
 
-	// Staring the gin server.
-	chunkRouter.Run(":", chunkPort)
+	// Staring the gin server in a go routine, so that the main routine can wait for the OS signals.
+	go func() {
+		err := chunkRouter.Run(":" + chunkPort)
+		if err != nil {
+			log.Fatalf("Error: %s", err.Error())
+		}
+	}()
 
-	// Getting the redis client.
-	redisClient := chunk_redis.GetRedisClient()
+	// Getting the job manager.
+	jobManager := job_manager.GetJobManager()
 
 	// Creating a channel to receive OS signals.
 	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
 	log.Println("Message: Application Started.")
 
 	// Block the main routine until a signal is received.
 	<-sigs
 
 	// Once a signal is received, call shutdown to clean up resources.
-	redisClient.ShutDown()
+	// The job manager is shut down first, as its workers use the session store and the redis client.
+	jobManager.ShutDown()
+	session_store.ShutDown()
+	chunk_redis.ShutDown()
 	log.Println("Message: Application Stopped.")
 }
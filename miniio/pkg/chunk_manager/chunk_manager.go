@@ -2,36 +2,25 @@ package chunk_manager
 
 import (
 	chunk_models "ImageUploadMiniIo/pkg/image_chunks/models"
-	redis_database "ImageUploadMiniIo/pkg/redis"
-	"encoding/json"
+	miniio "ImageUploadMiniIo/pkg/mini_io"
+	"ImageUploadMiniIo/pkg/session_store"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
-
-	"github.com/gin-gonic/gin"
-	"github.com/go-redis/redis/v8"
 )
 
-func getChunkDetails(sessionId string) (*chunk_models.FileDetails, error) {
-	// Get the redis client.
-	redisClient := redis_database.GetRedisClient()
-
+func getSessionData(sessionId string) (*chunk_models.SessionData, error) {
 	// Check if the session Id exists or it has expired.
-	jsonData, err := redisClient.Client.Get(redisClient.Ctx, sessionId).Result()
-	if err == redis.Nil {
-		return nil, fmt.Errorf("session expired")
-	} else if err != nil {
-		return nil, err
-	}
-
-	// Deserialise the json data into the models.Session structure.
-	var sessionData chunk_models.SessionData
-	err = json.Unmarshal([]byte(jsonData), &sessionData)
+	sessionData, _, err := session_store.GetSessionStore().Get(sessionId)
 	if err != nil {
 		return nil, err
 	}
 
-	return &sessionData.FileDetails, nil
+	return sessionData, nil
 }
 
 func createPermFolder(sessionId string) (string, error) {
@@ -68,7 +57,10 @@ func getTempFolderPath(sessionId string) (string, error) {
 	return tempFolderPath, nil
 }
 
-func saveChunkPermLocation(sessionId string, permFolderPathh string, tempFolderPath string, chunkDetails *chunk_models.FileDetails) error {
+// Error returned when the SHA-256 checksum of the compiled file does not match the one sent at session creation.
+var ErrFileChecksumMismatch = errors.New("file checksum mismatch")
+
+func saveChunkPermLocation(sessionId string, permFolderPathh string, tempFolderPath string, chunkDetails *chunk_models.FileDetails) (string, error) {
 	// Make the file name for permanent file.
 	fileName := fmt.Sprintf("%s.%s", sessionId, chunkDetails.FileType)
 	filePermPath := filepath.Join(permFolderPathh, "/"+fileName)
@@ -76,10 +68,14 @@ func saveChunkPermLocation(sessionId string, permFolderPathh string, tempFolderP
 	// Open the final file for writing.
 	permFile, err := os.Create(filePermPath)
 	if err != nil {
-		return err
+		return "", err
 	}
 	defer permFile.Close()
 
+	// Hash the bytes while they are being written into the final file.
+	hasher := sha256.New()
+	writer := io.MultiWriter(permFile, hasher)
+
 	// Reading each chunk file and writing it to the output file.
 	for i := 1; i <= chunkDetails.TotalChunks; i++ {
 		// Getting the chunk file name and path.
@@ -89,11 +85,72 @@ func saveChunkPermLocation(sessionId string, permFolderPathh string, tempFolderP
 		// Reading the chunk file.
 		chunkData, err := os.ReadFile(chunkFilePath)
 		if err != nil {
-			return err
+			return "", err
 		}
 
 		// Writing the chunk file into the final file.
-		_, err = permFile.Write(chunkData)
+		_, err = writer.Write(chunkData)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return hex.EncodeToString(hasher.Sum(nil)), nil
+}
+
+// Function to compile the chunks staged in the object store into the final file and return its SHA-256 checksum.
+func saveStagedChunksPermLocation(sessionId string, permFolderPath string, chunkDetails *chunk_models.FileDetails) (string, error) {
+	// Open the final file for writing.
+	fileName := fmt.Sprintf("%s.%s", sessionId, chunkDetails.FileType)
+	permFile, err := os.Create(filepath.Join(permFolderPath, fileName))
+	if err != nil {
+		return "", err
+	}
+	defer permFile.Close()
+
+	// Hash the bytes while they are being written into the final file.
+	hasher := sha256.New()
+	writer := io.MultiWriter(permFile, hasher)
+
+	// Streaming each staged chunk into the final file, in order.
+	for i := 1; i <= chunkDetails.TotalChunks; i++ {
+		err := copyStagedChunk(writer, sessionId, i)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return hex.EncodeToString(hasher.Sum(nil)), nil
+}
+
+// Function to copy a staged chunk of a particular session into the writer.
+func copyStagedChunk(writer io.Writer, sessionId string, chunkNumber int) error {
+	chunk, err := miniio.GetStagedChunkReader(sessionId, chunkNumber)
+	if err != nil {
+		return fmt.Errorf("could not read staged chunk %d: %w", chunkNumber, err)
+	}
+	defer chunk.Close()
+
+	_, err = io.Copy(writer, chunk)
+	return err
+}
+
+// Function to write the byte ranges staged in the object store at their positions in the file at the given path.
+func saveStagedRanges(sessionId string, filePath string) error {
+	byteRanges, err := miniio.ListStagedRanges(sessionId)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Create(filePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	// Overlapping ranges carry the same bytes, so they are simply written over each other.
+	for _, byteRange := range byteRanges {
+		err := copyStagedRange(io.NewOffsetWriter(file, byteRange.Start), sessionId, byteRange)
 		if err != nil {
 			return err
 		}
@@ -102,29 +159,159 @@ func saveChunkPermLocation(sessionId string, permFolderPathh string, tempFolderP
 	return nil
 }
 
-func CompileChunks(c *gin.Context, sessionId string) error {
+// Function to copy a staged byte range of a particular session into the writer.
+func copyStagedRange(writer io.Writer, sessionId string, byteRange chunk_models.ByteRange) error {
+	stagedRange, err := miniio.GetStagedRangeReader(sessionId, byteRange)
+	if err != nil {
+		return fmt.Errorf("could not read staged range %d-%d: %w", byteRange.Start, byteRange.End, err)
+	}
+	defer stagedRange.Close()
+
+	_, err = io.Copy(writer, stagedRange)
+	return err
+}
+
+// Function to complete the multipart upload the chunks have been streamed into and return the SHA-256 checksum of the object.
+// As the parts never touch the disk, the checksum is computed by reading the object back from the bucket.
+func compileMultipartUpload(sessionData *chunk_models.SessionData) (string, error) {
+	err := miniio.CompleteSessionMultipartUpload(sessionData)
+	if err != nil {
+		return "", err
+	}
+
+	fileChecksum, err := miniio.GetObjectChecksum(miniio.GetSessionMultipartObjectName(sessionData.SessionId))
+	if err != nil {
+		return "", err
+	}
+
+	// Verify the checksum of the object and remove it from the bucket on mismatch.
+	if sessionData.FileChecksum != "" && sessionData.FileChecksum != fileChecksum {
+		err = miniio.RemoveSessionMultipartObject(sessionData.SessionId)
+		if err != nil {
+			return fileChecksum, err
+		}
+
+		return fileChecksum, ErrFileChecksumMismatch
+	}
+
+	return fileChecksum, nil
+}
+
+// Function to compile the chunks into the final file and return its SHA-256 checksum.
+// If the client has sent the expected checksum of the file, the compiled file is verified against it.
+func CompileChunks(sessionId string) (string, error) {
+	// If the chunks have been streamed into a multipart upload, there is nothing to compile locally.
+	sessionData, err := getSessionData(sessionId)
+	if err != nil {
+		return "", err
+	}
+	if sessionData.MultipartUploadId != "" {
+		return compileMultipartUpload(sessionData)
+	}
+
 	// Create the permanent folder.
 	permFolderPath, err := createPermFolder(sessionId)
 	if err != nil {
-		return err
+		return "", err
 	}
 
-	// Get the chunk details from the client request.
-	chunkDetails, err := getChunkDetails(sessionId)
+	// Get the chunk details from the session data.
+	chunkDetails := &sessionData.FileDetails
+
+	// Permanently save the full file in the location.
+	// The chunks of a staged session are read from the object store, otherwise from the temp folder.
+	var fileChecksum string
+	if sessionData.Staged {
+		fileChecksum, err = saveStagedChunksPermLocation(sessionId, permFolderPath, chunkDetails)
+	} else {
+		// Get the temp folder path.
+		var tempFolderPath string
+		tempFolderPath, err = getTempFolderPath(sessionId)
+		if err != nil {
+			return "", err
+		}
+		fileChecksum, err = saveChunkPermLocation(sessionId, permFolderPath, tempFolderPath, chunkDetails)
+	}
 	if err != nil {
-		return err
+		return "", err
 	}
 
-	// Permanently save the full file in the location.
-	// Get the temp folder path.
-	tempFolderPath, err := getTempFolderPath(sessionId)
+	// Verify the checksum of the compiled file.
+	if chunkDetails.FileChecksum != "" && chunkDetails.FileChecksum != fileChecksum {
+		return fileChecksum, ErrFileChecksumMismatch
+	}
+
+	return fileChecksum, nil
+}
+
+// Function to get the path of the compiled file of a particular session in the permanent folder.
+func GetPermFilePath(sessionId string, fileType string) string {
+	// Get the environment variables.
+	permFolderPath := os.Getenv("FOLDER_PERM_PATH")
+
+	fileName := fmt.Sprintf("%s.%s", sessionId, fileType)
+	return filepath.Join(permFolderPath, sessionId, fileName)
+}
+
+// Function to get the path of the file the byte ranges of a particular session are written into.
+func GetRangeFilePath(sessionId string, fileType string) string {
+	// Get the environment variables.
+	tempFolderPath := os.Getenv("FOLDER_TEMP_PATH")
+
+	// The range file lives inside the temporary folder of the session.
+	fileName := fmt.Sprintf("%s_range.%s", sessionId, fileType)
+	return filepath.Join(tempFolderPath, "."+sessionId, fileName)
+}
+
+// Function to move the file assembled from byte ranges into the permanent location and return its SHA-256 checksum.
+// If the client has sent the expected checksum of the file, the assembled file is verified against it.
+func CompileRanges(sessionId string) (string, error) {
+	// Create the permanent folder.
+	permFolderPath, err := createPermFolder(sessionId)
 	if err != nil {
-		return err
+		return "", err
 	}
-	err = saveChunkPermLocation(sessionId, permFolderPath, tempFolderPath, chunkDetails)
+
+	// Get the session data.
+	sessionData, err := getSessionData(sessionId)
 	if err != nil {
-		return err
+		return "", err
 	}
+	chunkDetails := &sessionData.FileDetails
 
-	return nil
+	// Move the range file into the permanent location.
+	// The ranges of a staged session are written from the object store straight into the permanent location.
+	fileName := fmt.Sprintf("%s.%s", sessionId, chunkDetails.FileType)
+	filePermPath := filepath.Join(permFolderPath, fileName)
+	// An empty upload has no range file, so an empty file is created in its place.
+	if sessionData.Staged {
+		err = saveStagedRanges(sessionId, filePermPath)
+	} else if sessionData.TotalBytes == 0 {
+		err = os.WriteFile(filePermPath, nil, 0644)
+	} else {
+		err = os.Rename(GetRangeFilePath(sessionId, chunkDetails.FileType), filePermPath)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	// Compute the checksum of the assembled file.
+	permFile, err := os.Open(filePermPath)
+	if err != nil {
+		return "", err
+	}
+	defer permFile.Close()
+
+	hasher := sha256.New()
+	if _, err := io.Copy(hasher, permFile); err != nil {
+		return "", err
+	}
+	fileChecksum := hex.EncodeToString(hasher.Sum(nil))
+
+	// Verify the checksum of the assembled file.
+	if chunkDetails.FileChecksum != "" && chunkDetails.FileChecksum != fileChecksum {
+		return fileChecksum, ErrFileChecksumMismatch
+	}
+
+	return fileChecksum, nil
 }
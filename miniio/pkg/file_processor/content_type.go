@@ -0,0 +1,79 @@
+package file_processor
+
+import (
+	chunk_models "ImageUploadMiniIo/pkg/image_chunks/models"
+	"fmt"
+	"mime"
+	"os"
+	"strings"
+
+	"github.com/gabriel-vasile/mimetype"
+)
+
+// Content types accepted when no allow-list is configured.
+var defaultAllowedContentTypes = []string{"image/jpeg", "image/png", "image/gif", "image/webp"}
+
+// Function to get the content types accepted in the bucket, from the comma separated environment variable.
+func getAllowedContentTypes() []string {
+	// Loading the environment variables.
+	var allowedContentTypes []string
+	for _, contentType := range strings.Split(os.Getenv("ALLOWED_CONTENT_TYPES"), ",") {
+		contentType = strings.TrimSpace(contentType)
+		if contentType != "" {
+			allowedContentTypes = append(allowedContentTypes, contentType)
+		}
+	}
+
+	if len(allowedContentTypes) == 0 {
+		return defaultAllowedContentTypes
+	}
+
+	return allowedContentTypes
+}
+
+// Function to detect the content type of the compiled file of a particular session from its magic bytes.
+func detectContentType(sessionData *chunk_models.SessionData) (string, error) {
+	file, err := openSessionFile(sessionData)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	detected, err := mimetype.DetectReader(file)
+	if err != nil {
+		return "", err
+	}
+
+	// Drop the parameters, such as the charset of text files.
+	contentType, _, _ := strings.Cut(detected.String(), ";")
+
+	return contentType, nil
+}
+
+// Function to validate the detected content type against the file type declared by the client and the allow-list.
+func validateContentType(fileDetails chunk_models.FileDetails, contentType string) error {
+	detected := mimetype.Lookup(contentType)
+
+	// The detected content type, or one of its aliases, should be on the allow-list.
+	allowed := false
+	for _, allowedContentType := range getAllowedContentTypes() {
+		if contentType == allowedContentType || (detected != nil && detected.Is(allowedContentType)) {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		return fmt.Errorf("%w: content type \"%s\" is not allowed", ErrFileRejected, contentType)
+	}
+
+	// The detected content type should agree with the file type declared by the client.
+	declaredContentType, _, _ := strings.Cut(mime.TypeByExtension("."+strings.TrimPrefix(fileDetails.FileType, ".")), ";")
+	if declaredContentType == "" {
+		return fmt.Errorf("%w: declared file type \"%s\" is unknown", ErrFileRejected, fileDetails.FileType)
+	}
+	if contentType != declaredContentType && (detected == nil || !detected.Is(declaredContentType)) {
+		return fmt.Errorf("%w: content type \"%s\" does not match the declared file type \"%s\"", ErrFileRejected, contentType, fileDetails.FileType)
+	}
+
+	return nil
+}
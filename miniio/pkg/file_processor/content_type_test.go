@@ -0,0 +1,36 @@
+package file_processor
+
+import (
+	chunk_models "ImageUploadMiniIo/pkg/image_chunks/models"
+	"errors"
+	"testing"
+)
+
+func TestValidateContentType(t *testing.T) {
+	tests := []struct {
+		name                string
+		allowedContentTypes string
+		fileType            string
+		contentType         string
+		wantErr             bool
+	}{
+		{name: "jpeg declared as jpg", fileType: "jpg", contentType: "image/jpeg"},
+		{name: "upper case file type with a dot", fileType: ".PNG", contentType: "image/png"},
+		{name: "png declared as jpg", fileType: "jpg", contentType: "image/png", wantErr: true},
+		{name: "pdf not allowed", fileType: "pdf", contentType: "application/pdf", wantErr: true},
+		{name: "executable declared as png", fileType: "png", contentType: "application/x-elf", wantErr: true},
+		{name: "not on the configured list", allowedContentTypes: "image/png", fileType: "jpg", contentType: "image/jpeg", wantErr: true},
+		{name: "on the configured list", allowedContentTypes: "image/png, image/svg+xml", fileType: "svg", contentType: "image/svg+xml"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Setenv("ALLOWED_CONTENT_TYPES", test.allowedContentTypes)
+
+			err := validateContentType(chunk_models.FileDetails{FileType: test.fileType}, test.contentType)
+			if test.wantErr != errors.Is(err, ErrFileRejected) {
+				t.Errorf("error = %v, want rejected %v", err, test.wantErr)
+			}
+		})
+	}
+}
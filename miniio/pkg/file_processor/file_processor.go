@@ -0,0 +1,102 @@
+package file_processor
+
+import (
+	"ImageUploadMiniIo/pkg/chunk_manager"
+	file_models "ImageUploadMiniIo/pkg/file_processor/models"
+	chunk_models "ImageUploadMiniIo/pkg/image_chunks/models"
+	miniio "ImageUploadMiniIo/pkg/mini_io"
+	"errors"
+	"io"
+	"os"
+)
+
+// Error wrapped by every check rejecting the compiled file, the session cannot be recovered from it.
+var ErrFileRejected = errors.New("file rejected")
+
+// Function to open the compiled file of a particular session for reading.
+// If the chunks have been streamed into a multipart upload, the file is read back from the bucket.
+func openSessionFile(sessionData *chunk_models.SessionData) (io.ReadCloser, error) {
+	if sessionData.MultipartUploadId != "" {
+		return miniio.GetObjectReader(miniio.GetSessionMultipartObjectName(sessionData.SessionId))
+	}
+
+	return os.Open(chunk_manager.GetPermFilePath(sessionData.SessionId, sessionData.FileType))
+}
+
+// Function to run the checks and processing steps on the compiled file of a particular session,
+// before it is stored in the mini-io bucket.
+// Returns the report of the file, which is stored along with it as its metadata.
+func ProcessSessionFile(sessionData *chunk_models.SessionData, fileChecksum string) (*file_models.FileReport, error) {
+	var fileReport file_models.FileReport
+	fileReport.FileChecksum = fileChecksum
+
+	// The compiled file should be as large as the client has declared.
+	err := validateFileSize(sessionData)
+	if err != nil {
+		return nil, err
+	}
+
+	// Scan the file for viruses, before anything parses its content.
+	scanResult, err := scanSessionFile(sessionData)
+	if err != nil {
+		return nil, err
+	}
+	fileReport.Scan = scanResult
+
+	// Detect the content type from the content of the file and validate it.
+	contentType, err := detectContentType(sessionData)
+	if err != nil {
+		return nil, err
+	}
+	err = validateContentType(sessionData.FileDetails, contentType)
+	if err != nil {
+		return nil, err
+	}
+	fileReport.ContentType = contentType
+
+	// Check the dimensions of the image, before any of the steps below decodes it.
+	err = validateImageDimensions(sessionData)
+	if err != nil {
+		return nil, err
+	}
+
+	// Extract the properties of the image, while its EXIF data is still there.
+	imageProperties, err := extractImageProperties(sessionData)
+	if err != nil {
+		return nil, err
+	}
+	fileReport.Image = imageProperties
+
+	// Strip the metadata of the image, such as its GPS coordinates, before anything else reads it.
+	// The stored file is the stripped one, so its checksum replaces the one of the compiled file.
+	if isMetadataStrippingEnabled(sessionData.FileDetails) && isMetadataStrippingSupported(contentType) {
+		strippedChecksum, err := stripSessionFileMetadata(sessionData, contentType)
+		if err != nil {
+			return nil, err
+		}
+		fileReport.OriginalChecksum = fileChecksum
+		fileReport.FileChecksum = strippedChecksum
+		fileReport.MetadataStripped = true
+	}
+
+	// Decode the image once for the steps working on its pixels.
+	if !isDecodingSupported(contentType) {
+		return &fileReport, nil
+	}
+	img, err := decodeSessionImage(sessionData)
+	if err != nil {
+		return nil, err
+	}
+
+	// Generate the smaller renditions of the image, which are stored alongside it.
+	renditions, err := generateRenditions(img, contentType)
+	if err != nil {
+		return nil, err
+	}
+	fileReport.Renditions = renditions
+
+	// Compute the perceptual hash of the image, used to find resized or recompressed copies of it.
+	fileReport.PerceptualHash = computePerceptualHash(img)
+
+	return &fileReport, nil
+}
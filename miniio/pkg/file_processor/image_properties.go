@@ -0,0 +1,300 @@
+package file_processor
+
+import (
+	file_models "ImageUploadMiniIo/pkg/file_processor/models"
+	chunk_models "ImageUploadMiniIo/pkg/image_chunks/models"
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"strings"
+)
+
+// Tags of the EXIF IFD0 entries read from the image.
+const (
+	exifTagMake        = 0x010F
+	exifTagModel       = 0x0110
+	exifTagOrientation = 0x0112
+)
+
+// Largest EXIF data read from an image, which fits in a single jpeg APP1 segment.
+const maxExifSize = 64 * 1024
+
+// Names of the color models of the standard library, as stored in the image properties.
+var colorModelNames = map[color.Model]string{
+	color.RGBAModel:    "rgba",
+	color.RGBA64Model:  "rgba64",
+	color.NRGBAModel:   "nrgba",
+	color.NRGBA64Model: "nrgba64",
+	color.AlphaModel:   "alpha",
+	color.Alpha16Model: "alpha16",
+	color.GrayModel:    "gray",
+	color.Gray16Model:  "gray16",
+	color.CMYKModel:    "cmyk",
+	color.YCbCrModel:   "ycbcr",
+	color.NYCbCrAModel: "nycbcra",
+}
+
+// Function to get the name of a color model.
+func getColorModelName(colorModel color.Model) string {
+	// A palette is a slice, so it is matched on its type before the map is looked up.
+	if _, ok := colorModel.(color.Palette); ok {
+		return "paletted"
+	}
+	if name, ok := colorModelNames[colorModel]; ok {
+		return name
+	}
+
+	return "unknown"
+}
+
+// Function to extract the properties of the compiled image of a particular session, from its header and EXIF data.
+// Returns nil for images in a format without a registered decoder.
+func extractImageProperties(sessionData *chunk_models.SessionData) (*file_models.ImageProperties, error) {
+	file, err := openSessionFile(sessionData)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	// Keep the bytes read for the header, so that the rest of the file can be read after it.
+	var header bytes.Buffer
+	config, format, err := image.DecodeConfig(io.TeeReader(file, &header))
+	if errors.Is(err, image.ErrFormat) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("%w: image header could not be decoded: %s", ErrFileRejected, err.Error())
+	}
+
+	var imageProperties file_models.ImageProperties
+	imageProperties.Width = config.Width
+	imageProperties.Height = config.Height
+	imageProperties.Format = format
+	imageProperties.ColorModel = getColorModelName(config.ColorModel)
+
+	// The frames and EXIF data are best effort, an image which cannot be read that far is still stored.
+	reader := bufio.NewReader(io.MultiReader(&header, file))
+	switch format {
+	case "gif":
+		if frameCount, err := countGifFrames(reader); err == nil {
+			imageProperties.FrameCount = frameCount
+		}
+	case "jpeg", "png":
+		var exifData []byte
+		if format == "jpeg" {
+			exifData, err = findJpegExif(reader)
+		} else {
+			exifData, err = findPngExif(reader)
+		}
+		if err == nil && exifData != nil {
+			parseExif(exifData, &imageProperties)
+		}
+	}
+
+	return &imageProperties, nil
+}
+
+// Function to skip the data sub-blocks of a gif block, up to and including the block terminator.
+func skipGifSubBlocks(reader *bufio.Reader) error {
+	for {
+		size, err := reader.ReadByte()
+		if err != nil {
+			return err
+		}
+		if size == 0 {
+			return nil
+		}
+		if _, err := reader.Discard(int(size)); err != nil {
+			return err
+		}
+	}
+}
+
+// Function to count the frames of a gif image by walking its blocks, without decoding any of them.
+func countGifFrames(reader *bufio.Reader) (int, error) {
+	// Skip the header and the logical screen descriptor, along with the global color table if any.
+	screenDescriptor := make([]byte, 13)
+	if _, err := io.ReadFull(reader, screenDescriptor); err != nil {
+		return 0, err
+	}
+	if screenDescriptor[10]&0x80 != 0 {
+		if _, err := reader.Discard(3 << (int(screenDescriptor[10]&0x07) + 1)); err != nil {
+			return 0, err
+		}
+	}
+
+	frameCount := 0
+	for {
+		introducer, err := reader.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+
+		switch introducer {
+		case 0x2C:
+			// Skip the image descriptor, the local color table if any and the image data.
+			imageDescriptor := make([]byte, 9)
+			if _, err := io.ReadFull(reader, imageDescriptor); err != nil {
+				return 0, err
+			}
+			if imageDescriptor[8]&0x80 != 0 {
+				if _, err := reader.Discard(3 << (int(imageDescriptor[8]&0x07) + 1)); err != nil {
+					return 0, err
+				}
+			}
+			if _, err := reader.ReadByte(); err != nil {
+				return 0, err
+			}
+			if err := skipGifSubBlocks(reader); err != nil {
+				return 0, err
+			}
+			frameCount++
+		case 0x21:
+			// Skip the label and the data of the extension.
+			if _, err := reader.ReadByte(); err != nil {
+				return 0, err
+			}
+			if err := skipGifSubBlocks(reader); err != nil {
+				return 0, err
+			}
+		case 0x3B:
+			return frameCount, nil
+		default:
+			return 0, fmt.Errorf("invalid gif block introducer")
+		}
+	}
+}
+
+// Function to find the EXIF data of a jpeg image, stored in an APP1 segment before the start of scan.
+// Returns nil if the image has no EXIF data.
+func findJpegExif(reader *bufio.Reader) ([]byte, error) {
+	if _, err := reader.Discard(2); err != nil {
+		return nil, err
+	}
+
+	for {
+		marker := make([]byte, 2)
+		if _, err := io.ReadFull(reader, marker); err != nil {
+			return nil, err
+		}
+		if marker[0] != 0xFF || marker[1] == 0xDA || marker[1] == 0xD9 {
+			return nil, nil
+		}
+
+		lengthBytes := make([]byte, 2)
+		if _, err := io.ReadFull(reader, lengthBytes); err != nil {
+			return nil, err
+		}
+		length := int(binary.BigEndian.Uint16(lengthBytes))
+		if length < 2 {
+			return nil, fmt.Errorf("invalid jpeg segment length")
+		}
+		segment := make([]byte, length-2)
+		if _, err := io.ReadFull(reader, segment); err != nil {
+			return nil, err
+		}
+
+		if marker[1] == 0xE1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+			return segment[6:], nil
+		}
+	}
+}
+
+// Function to find the EXIF data of a png image, stored in its eXIf chunk.
+// Returns nil if the image has no EXIF data.
+func findPngExif(reader *bufio.Reader) ([]byte, error) {
+	if _, err := reader.Discard(len(pngSignature)); err != nil {
+		return nil, err
+	}
+
+	for {
+		header := make([]byte, 8)
+		if _, err := io.ReadFull(reader, header); err != nil {
+			return nil, err
+		}
+		length := int(binary.BigEndian.Uint32(header[:4]))
+		chunkType := string(header[4:])
+
+		switch chunkType {
+		case "eXIf":
+			// The length is taken from the file, so it is checked before anything is read.
+			if length > maxExifSize {
+				return nil, fmt.Errorf("png eXIf chunk of %d bytes exceeds the maximum size of %d bytes", length, maxExifSize)
+			}
+			var exifData bytes.Buffer
+			if _, err := io.CopyN(&exifData, reader, int64(length)); err != nil {
+				return nil, err
+			}
+			return exifData.Bytes(), nil
+		case "IDAT", "IEND":
+			// The eXIf chunk should come before the image data.
+			return nil, nil
+		}
+
+		if _, err := reader.Discard(length + 4); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// Function to read the camera make, model and orientation from the IFD0 of the EXIF data, which is laid out as a tiff file.
+// Entries which cannot be read are left out.
+func parseExif(exifData []byte, imageProperties *file_models.ImageProperties) {
+	if len(exifData) < 8 {
+		return
+	}
+
+	var byteOrder binary.ByteOrder
+	switch string(exifData[:2]) {
+	case "II":
+		byteOrder = binary.LittleEndian
+	case "MM":
+		byteOrder = binary.BigEndian
+	default:
+		return
+	}
+
+	ifdOffset := int(byteOrder.Uint32(exifData[4:8]))
+	if ifdOffset < 8 || ifdOffset+2 > len(exifData) {
+		return
+	}
+	entryCount := int(byteOrder.Uint16(exifData[ifdOffset:]))
+
+	// Every entry is made of its tag, type, count and value, or the offset of the value if it takes more than four bytes.
+	for i := 0; i < entryCount; i++ {
+		entryOffset := ifdOffset + 2 + i*12
+		if entryOffset+12 > len(exifData) {
+			return
+		}
+		entry := exifData[entryOffset : entryOffset+12]
+		tag := byteOrder.Uint16(entry[0:2])
+		count := int(byteOrder.Uint32(entry[4:8]))
+
+		switch tag {
+		case exifTagOrientation:
+			imageProperties.Orientation = int(byteOrder.Uint16(entry[8:10]))
+		case exifTagMake, exifTagModel:
+			value := entry[8:12]
+			if count > 4 {
+				valueOffset := int(byteOrder.Uint32(entry[8:12]))
+				if valueOffset < 0 || valueOffset+count > len(exifData) {
+					continue
+				}
+				value = exifData[valueOffset : valueOffset+count]
+			} else {
+				value = value[:count]
+			}
+
+			text := strings.TrimSpace(strings.TrimRight(string(value), "\x00"))
+			if tag == exifTagMake {
+				imageProperties.CameraMake = text
+			} else {
+				imageProperties.CameraModel = text
+			}
+		}
+	}
+}
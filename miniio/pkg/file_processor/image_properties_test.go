@@ -0,0 +1,43 @@
+package file_processor
+
+import (
+	"bufio"
+	"bytes"
+	"testing"
+)
+
+func TestFindPngExif(t *testing.T) {
+	ihdr := pngChunk("IHDR", "\x00\x00\x00\x01\x00\x00\x00\x01\x08\x00\x00\x00\x00")
+	exifData := string(buildOrientationExif(6))
+
+	tests := []struct {
+		name     string
+		chunks   [][]byte
+		wantExif string
+		wantErr  bool
+	}{
+		{name: "exif found", chunks: [][]byte{ihdr, pngChunk("eXIf", exifData), pngChunk("IDAT", "pixels")}, wantExif: exifData},
+		{name: "no exif before the image data", chunks: [][]byte{ihdr, pngChunk("IDAT", "pixels"), pngChunk("eXIf", exifData)}},
+		{name: "oversized exif length", chunks: [][]byte{ihdr, []byte("\xff\xff\xff\xf0eXIf")}, wantErr: true},
+		{name: "truncated exif", chunks: [][]byte{ihdr, pngChunk("eXIf", exifData)[:12]}, wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			input := append(append([]byte{}, pngSignature...), bytes.Join(test.chunks, nil)...)
+			found, err := findPngExif(bufio.NewReader(bytes.NewReader(input)))
+			if test.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got %d bytes", len(found))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(found) != test.wantExif {
+				t.Errorf("exif = % x, want % x", found, test.wantExif)
+			}
+		})
+	}
+}
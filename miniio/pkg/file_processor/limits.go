@@ -0,0 +1,104 @@
+package file_processor
+
+import (
+	"ImageUploadMiniIo/pkg/chunk_manager"
+	chunk_models "ImageUploadMiniIo/pkg/image_chunks/models"
+	miniio "ImageUploadMiniIo/pkg/mini_io"
+	"errors"
+	"fmt"
+	"image"
+	"os"
+	"strconv"
+
+	_ "golang.org/x/image/webp"
+)
+
+// Limits on the dimensions of an image used when none are configured.
+const (
+	defaultMaxImageWidth  = 16384
+	defaultMaxImageHeight = 16384
+	defaultMaxImagePixels = 50_000_000
+)
+
+// Function to get a limit from the environment variable, the default when it is not set or invalid.
+func getLimit(name string, defaultLimit int64) int64 {
+	// Loading the environment variables.
+	limit, err := strconv.ParseInt(os.Getenv(name), 10, 64)
+	if err != nil || limit <= 0 {
+		return defaultLimit
+	}
+
+	return limit
+}
+
+// Function to get the size in bytes of the compiled file of a particular session.
+func getSessionFileSize(sessionData *chunk_models.SessionData) (int64, error) {
+	if sessionData.MultipartUploadId != "" {
+		return miniio.GetObjectSize(miniio.GetSessionMultipartObjectName(sessionData.SessionId))
+	}
+
+	fileInfo, err := os.Stat(chunk_manager.GetPermFilePath(sessionData.SessionId, sessionData.FileType))
+	if err != nil {
+		return 0, err
+	}
+
+	return fileInfo.Size(), nil
+}
+
+// Function to validate the size of the compiled file against the file size declared by the client.
+func validateFileSize(sessionData *chunk_models.SessionData) error {
+	// The file size is optional when the client has declared the number of chunks instead.
+	if sessionData.FileSize <= 0 {
+		return nil
+	}
+
+	fileSize, err := getSessionFileSize(sessionData)
+	if err != nil {
+		return err
+	}
+
+	matches, err := sessionData.FileDetails.MatchesSize(fileSize)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrFileRejected, err.Error())
+	}
+	if !matches {
+		return fmt.Errorf("%w: file size %d bytes does not match the declared file size %d %s", ErrFileRejected, fileSize, sessionData.FileSize, sessionData.FileSizeUnit)
+	}
+
+	return nil
+}
+
+// Function to validate the dimensions of the compiled image against the configured limits, before it is decoded.
+// Only the header of the image is read, so an image which would take too much memory to decode is rejected cheaply.
+// Images in a format without a registered decoder are not checked, the decoders of every default allowed content type are registered.
+func validateImageDimensions(sessionData *chunk_models.SessionData) error {
+	file, err := openSessionFile(sessionData)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	config, _, err := image.DecodeConfig(file)
+	if errors.Is(err, image.ErrFormat) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("%w: image header could not be decoded: %s", ErrFileRejected, err.Error())
+	}
+
+	maxWidth := getLimit("IMAGE_MAX_WIDTH", defaultMaxImageWidth)
+	maxHeight := getLimit("IMAGE_MAX_HEIGHT", defaultMaxImageHeight)
+	maxPixels := getLimit("IMAGE_MAX_PIXELS", defaultMaxImagePixels)
+
+	width, height := int64(config.Width), int64(config.Height)
+	if width <= 0 || height <= 0 {
+		return fmt.Errorf("%w: image has invalid dimensions %dx%d", ErrFileRejected, width, height)
+	}
+	if width > maxWidth || height > maxHeight {
+		return fmt.Errorf("%w: image dimensions %dx%d exceed the limit of %dx%d", ErrFileRejected, width, height, maxWidth, maxHeight)
+	}
+	if width*height > maxPixels {
+		return fmt.Errorf("%w: image has %d pixels, exceeding the limit of %d", ErrFileRejected, width*height, maxPixels)
+	}
+
+	return nil
+}
@@ -0,0 +1,279 @@
+package file_processor
+
+import (
+	"ImageUploadMiniIo/pkg/chunk_manager"
+	file_models "ImageUploadMiniIo/pkg/file_processor/models"
+	chunk_models "ImageUploadMiniIo/pkg/image_chunks/models"
+	miniio "ImageUploadMiniIo/pkg/mini_io"
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+)
+
+// Signature every png file starts with.
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+// Png chunks carrying metadata, the text chunks may hold XMP and the eXIf chunk the GPS coordinates.
+var pngMetadataChunks = map[string]bool{
+	"eXIf": true,
+	"tEXt": true,
+	"zTXt": true,
+	"iTXt": true,
+	"iCCP": true,
+	"tIME": true,
+}
+
+// Function to check whether the metadata of the compiled file should be stripped.
+// The bucket setting takes precedence, the choice of the client is only used when the bucket leaves it open.
+func isMetadataStrippingEnabled(fileDetails chunk_models.FileDetails) bool {
+	// Loading the environment variables.
+	switch os.Getenv("STRIP_METADATA") {
+	case "true":
+		return true
+	case "false":
+		return false
+	}
+
+	return fileDetails.StripMetadata != nil && *fileDetails.StripMetadata
+}
+
+// Function to check whether the metadata can be stripped from a particular content type.
+func isMetadataStrippingSupported(contentType string) bool {
+	return contentType == "image/jpeg" || contentType == "image/png"
+}
+
+// Function to get the orientation of the image from its EXIF data, zero if it has none or the default one.
+func getExifOrientation(exifData []byte) int {
+	var imageProperties file_models.ImageProperties
+	parseExif(exifData, &imageProperties)
+	if imageProperties.Orientation < 2 || imageProperties.Orientation > 8 {
+		return 0
+	}
+
+	return imageProperties.Orientation
+}
+
+// Function to build EXIF data holding nothing but the orientation of the image, laid out as a big endian tiff file
+// with a single entry in its IFD0.
+func buildOrientationExif(orientation int) []byte {
+	exifData := []byte("MM\x00\x2A\x00\x00\x00\x08")
+	exifData = binary.BigEndian.AppendUint16(exifData, 1)
+	exifData = binary.BigEndian.AppendUint16(exifData, exifTagOrientation)
+	exifData = binary.BigEndian.AppendUint16(exifData, 3)
+	exifData = binary.BigEndian.AppendUint32(exifData, 1)
+	exifData = binary.BigEndian.AppendUint16(exifData, uint16(orientation))
+	exifData = append(exifData, 0, 0)
+
+	return binary.BigEndian.AppendUint32(exifData, 0)
+}
+
+// Function to copy a jpeg file, leaving out the segments carrying metadata.
+// The JFIF and Adobe segments are kept, as the latter decides how the colors of the image are decoded.
+// The EXIF segment is replaced with one holding only the orientation, if any, so that the image is still displayed upright.
+// Everything from the start of scan onwards is copied as it is, so the pixel data is untouched.
+func stripJpegMetadata(reader io.Reader, writer io.Writer) error {
+	bufferedReader := bufio.NewReader(reader)
+
+	// The file should start with the start of image marker.
+	soi := make([]byte, 2)
+	if _, err := io.ReadFull(bufferedReader, soi); err != nil || soi[0] != 0xFF || soi[1] != 0xD8 {
+		return fmt.Errorf("missing jpeg start of image marker")
+	}
+	if _, err := writer.Write(soi); err != nil {
+		return err
+	}
+
+	for {
+		// Every segment starts with a marker, which may be preceded by fill bytes.
+		markerByte, err := bufferedReader.ReadByte()
+		if err != nil {
+			return err
+		}
+		if markerByte != 0xFF {
+			return fmt.Errorf("invalid jpeg marker")
+		}
+		marker, err := bufferedReader.ReadByte()
+		for err == nil && marker == 0xFF {
+			marker, err = bufferedReader.ReadByte()
+		}
+		if err != nil {
+			return err
+		}
+
+		// The end of image marker carries no length.
+		if marker == 0xD9 {
+			_, err = writer.Write([]byte{0xFF, marker})
+			return err
+		}
+
+		// Read the segment, whose length includes the two bytes of the length itself.
+		lengthBytes := make([]byte, 2)
+		if _, err := io.ReadFull(bufferedReader, lengthBytes); err != nil {
+			return err
+		}
+		length := int(binary.BigEndian.Uint16(lengthBytes))
+		if length < 2 {
+			return fmt.Errorf("invalid jpeg segment length")
+		}
+		segment := make([]byte, length-2)
+		if _, err := io.ReadFull(bufferedReader, segment); err != nil {
+			return err
+		}
+
+		// Keep only the orientation of the EXIF segment.
+		if marker == 0xE1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+			orientation := getExifOrientation(segment[6:])
+			if orientation == 0 {
+				continue
+			}
+			segment = append([]byte("Exif\x00\x00"), buildOrientationExif(orientation)...)
+			binary.BigEndian.PutUint16(lengthBytes, uint16(len(segment)+2))
+		} else {
+			// Leave out the application segments other than JFIF and Adobe, and the comments.
+			isApplicationSegment := marker >= 0xE0 && marker <= 0xEF
+			if (isApplicationSegment && marker != 0xE0 && marker != 0xEE) || marker == 0xFE {
+				continue
+			}
+		}
+
+		if _, err := writer.Write([]byte{0xFF, marker}); err != nil {
+			return err
+		}
+		if _, err := writer.Write(lengthBytes); err != nil {
+			return err
+		}
+		if _, err := writer.Write(segment); err != nil {
+			return err
+		}
+
+		// Copy the rest of the file once the start of scan is reached.
+		if marker == 0xDA {
+			_, err = io.Copy(writer, bufferedReader)
+			return err
+		}
+	}
+}
+
+// Function to copy a png file, leaving out the chunks carrying metadata.
+// The eXIf chunk is replaced with one holding only the orientation, if any, so that the image is still displayed upright.
+// The image data chunks are copied as they are, so the pixel data is untouched.
+func stripPngMetadata(reader io.Reader, writer io.Writer) error {
+	bufferedReader := bufio.NewReader(reader)
+
+	// The file should start with the png signature.
+	signature := make([]byte, len(pngSignature))
+	if _, err := io.ReadFull(bufferedReader, signature); err != nil || !bytes.Equal(signature, pngSignature) {
+		return fmt.Errorf("missing png signature")
+	}
+	if _, err := writer.Write(signature); err != nil {
+		return err
+	}
+
+	for {
+		// Every chunk is made of its length, type, data and crc.
+		header := make([]byte, 8)
+		if _, err := io.ReadFull(bufferedReader, header); err != nil {
+			return err
+		}
+		length := int64(binary.BigEndian.Uint32(header[:4]))
+		chunkType := string(header[4:])
+
+		// An eXIf chunk too large to be read is left out along with its orientation.
+		if chunkType == "eXIf" && length <= maxExifSize {
+			var exifData bytes.Buffer
+			if _, err := io.CopyN(&exifData, bufferedReader, length+4); err != nil {
+				return err
+			}
+			orientation := getExifOrientation(exifData.Bytes()[:length])
+			if orientation == 0 {
+				continue
+			}
+
+			// The crc of a chunk covers its type and data.
+			data := append([]byte(chunkType), buildOrientationExif(orientation)...)
+			chunk := binary.BigEndian.AppendUint32(nil, uint32(len(data)-4))
+			chunk = append(chunk, data...)
+			chunk = binary.BigEndian.AppendUint32(chunk, crc32.ChecksumIEEE(data))
+			if _, err := writer.Write(chunk); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if pngMetadataChunks[chunkType] {
+			if _, err := io.CopyN(io.Discard, bufferedReader, length+4); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if _, err := writer.Write(header); err != nil {
+			return err
+		}
+		if _, err := io.CopyN(writer, bufferedReader, length+4); err != nil {
+			return err
+		}
+
+		if chunkType == "IEND" {
+			return nil
+		}
+	}
+}
+
+// Function to strip the metadata from the compiled file of a particular session, replacing the file.
+// Returns the SHA-256 checksum of the stripped file.
+func stripSessionFileMetadata(sessionData *chunk_models.SessionData, contentType string) (string, error) {
+	source, err := openSessionFile(sessionData)
+	if err != nil {
+		return "", err
+	}
+	defer source.Close()
+
+	// Write the stripped file next to the compiled one, or into the temporary directory if the compiled
+	// file is only in the bucket.
+	var strippedFile *os.File
+	permFilePath := chunk_manager.GetPermFilePath(sessionData.SessionId, sessionData.FileType)
+	if sessionData.MultipartUploadId != "" {
+		strippedFile, err = os.CreateTemp("", sessionData.SessionId+"-*")
+	} else {
+		strippedFile, err = os.Create(permFilePath + ".stripped")
+	}
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(strippedFile.Name())
+	defer strippedFile.Close()
+
+	hasher := sha256.New()
+	writer := io.MultiWriter(strippedFile, hasher)
+	if contentType == "image/jpeg" {
+		err = stripJpegMetadata(source, writer)
+	} else {
+		err = stripPngMetadata(source, writer)
+	}
+	if err != nil {
+		return "", fmt.Errorf("%w: metadata could not be stripped: %s", ErrFileRejected, err.Error())
+	}
+	err = strippedFile.Close()
+	if err != nil {
+		return "", err
+	}
+
+	// Replace the compiled file with the stripped one.
+	if sessionData.MultipartUploadId != "" {
+		err = miniio.ReplaceObjectContent(miniio.GetSessionMultipartObjectName(sessionData.SessionId), strippedFile.Name(), contentType)
+	} else {
+		err = os.Rename(strippedFile.Name(), permFilePath)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(hasher.Sum(nil)), nil
+}
@@ -0,0 +1,79 @@
+package file_processor
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+	"strings"
+	"testing"
+)
+
+// Function to build a jpeg segment from its marker and payload.
+func jpegSegment(marker byte, payload string) []byte {
+	segment := []byte{0xFF, marker, 0, 0}
+	binary.BigEndian.PutUint16(segment[2:], uint16(len(payload)+2))
+	return append(segment, payload...)
+}
+
+// Function to build a png chunk from its type and data.
+func pngChunk(chunkType string, data string) []byte {
+	chunk := binary.BigEndian.AppendUint32(nil, uint32(len(data)))
+	chunk = append(chunk, chunkType+data...)
+	return binary.BigEndian.AppendUint32(chunk, crc32.ChecksumIEEE([]byte(chunkType+data)))
+}
+
+func TestStripMetadata(t *testing.T) {
+	soi := []byte{0xFF, 0xD8}
+	jfif := jpegSegment(0xE0, "JFIF\x00")
+	exif := jpegSegment(0xE1, "Exif\x00\x00GPS")
+	comment := jpegSegment(0xFE, "a comment")
+	scan := append(jpegSegment(0xDA, "\x01scan"), 0x12, 0xFF, 0x00, 0xFF, 0xD9)
+	orientationExif := string(buildOrientationExif(6))
+	rotatedExif := jpegSegment(0xE1, "Exif\x00\x00"+orientationExif+"GPS")
+	keptExif := jpegSegment(0xE1, "Exif\x00\x00"+orientationExif)
+
+	ihdr := pngChunk("IHDR", "\x00\x00\x00\x01\x00\x00\x00\x01\x08\x00\x00\x00\x00")
+	text := pngChunk("tEXt", "Author\x00someone")
+	idat := pngChunk("IDAT", "pixels")
+	rotatedPngExif := pngChunk("eXIf", orientationExif+"GPS")
+	keptPngExif := pngChunk("eXIf", orientationExif)
+	iend := pngChunk("IEND", "")
+
+	tests := []struct {
+		name    string
+		strip   func(reader io.Reader, writer io.Writer) error
+		input   []byte
+		want    []byte
+		wantErr bool
+	}{
+		{name: "jpeg metadata removed", strip: stripJpegMetadata, input: bytes.Join([][]byte{soi, jfif, exif, comment, scan}, nil), want: bytes.Join([][]byte{soi, jfif, scan}, nil)},
+		{name: "jpeg orientation kept", strip: stripJpegMetadata, input: bytes.Join([][]byte{soi, jfif, rotatedExif, scan}, nil), want: bytes.Join([][]byte{soi, jfif, keptExif, scan}, nil)},
+		{name: "jpeg truncated segment", strip: stripJpegMetadata, input: bytes.Join([][]byte{soi, exif[:len(exif)-2]}, nil), wantErr: true},
+		{name: "not a jpeg", strip: stripJpegMetadata, input: pngSignature, wantErr: true},
+		{name: "png metadata removed", strip: stripPngMetadata, input: bytes.Join([][]byte{pngSignature, ihdr, text, idat, iend}, nil), want: bytes.Join([][]byte{pngSignature, ihdr, idat, iend}, nil)},
+		{name: "png orientation kept", strip: stripPngMetadata, input: bytes.Join([][]byte{pngSignature, ihdr, rotatedPngExif, idat, iend}, nil), want: bytes.Join([][]byte{pngSignature, ihdr, keptPngExif, idat, iend}, nil)},
+		{name: "png oversized exif removed", strip: stripPngMetadata, input: bytes.Join([][]byte{pngSignature, ihdr, pngChunk("eXIf", orientationExif+strings.Repeat("\x00", maxExifSize)), idat, iend}, nil), want: bytes.Join([][]byte{pngSignature, ihdr, idat, iend}, nil)},
+		{name: "png missing the end chunk", strip: stripPngMetadata, input: bytes.Join([][]byte{pngSignature, ihdr, idat}, nil), wantErr: true},
+		{name: "not a png", strip: stripPngMetadata, input: soi, wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var output bytes.Buffer
+			err := test.strip(bytes.NewReader(test.input), &output)
+			if test.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got % x", output.Bytes())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !bytes.Equal(output.Bytes(), test.want) {
+				t.Errorf("output = % x, want % x", output.Bytes(), test.want)
+			}
+		})
+	}
+}
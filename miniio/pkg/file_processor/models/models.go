@@ -0,0 +1,48 @@
+package models
+
+import "time"
+
+// Statuses of the virus scan of a file.
+const (
+	ScanClean    = "clean"
+	ScanInfected = "infected"
+)
+
+type FileReport struct {
+	FileChecksum     string           `json:"file_checksum"`
+	OriginalChecksum string           `json:"original_checksum,omitempty"`
+	ContentType      string           `json:"content_type,omitempty"`
+	MetadataStripped bool             `json:"metadata_stripped,omitempty"`
+	Image            *ImageProperties `json:"image,omitempty"`
+	Renditions       []*Rendition     `json:"renditions,omitempty"`
+	PerceptualHash   string           `json:"perceptual_hash,omitempty"`
+	Scan             *ScanResult      `json:"scan,omitempty"`
+}
+
+type Rendition struct {
+	Size        int    `json:"size"`
+	Width       int    `json:"width"`
+	Height      int    `json:"height"`
+	ContentType string `json:"content_type"`
+	Extension   string `json:"extension"`
+	ObjectName  string `json:"object_name,omitempty"`
+	Data        []byte `json:"-"`
+}
+
+type ImageProperties struct {
+	Width       int    `json:"width"`
+	Height      int    `json:"height"`
+	Format      string `json:"format"`
+	ColorModel  string `json:"color_model"`
+	Orientation int    `json:"orientation,omitempty"`
+	FrameCount  int    `json:"frame_count,omitempty"`
+	CameraMake  string `json:"camera_make,omitempty"`
+	CameraModel string `json:"camera_model,omitempty"`
+}
+
+type ScanResult struct {
+	Scanner   string    `json:"scanner"`
+	Status    string    `json:"status"`
+	Signature string    `json:"signature,omitempty"`
+	ScanTime  time.Time `json:"scan_time"`
+}
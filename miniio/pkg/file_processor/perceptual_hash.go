@@ -0,0 +1,32 @@
+package file_processor
+
+import (
+	"fmt"
+	"image"
+)
+
+// Function to compute the difference hash of a decoded image, as 16 hexadecimal characters.
+// The image is shrunk to 9x8 gray pixels and every bit tells whether a pixel is brighter than its right neighbour,
+// so resized or recompressed copies of the image end up within a small Hamming distance of each other.
+func computePerceptualHash(source *image.RGBA) string {
+	shrunk := resizeImage(source, 9, 8)
+
+	var hash uint64
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			hash <<= 1
+			if getLuminance(shrunk, x, y) > getLuminance(shrunk, x+1, y) {
+				hash |= 1
+			}
+		}
+	}
+
+	return fmt.Sprintf("%016x", hash)
+}
+
+// Function to get the luminance of a pixel, using the weights of the ITU-R BT.601 standard.
+func getLuminance(img *image.RGBA, x int, y int) int {
+	offset := img.PixOffset(x, y)
+
+	return 299*int(img.Pix[offset]) + 587*int(img.Pix[offset+1]) + 114*int(img.Pix[offset+2])
+}
@@ -0,0 +1,50 @@
+package file_processor
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+// Function to create a gray image whose levels are given by a function of the pixel position.
+func newGrayImage(width int, height int, level func(x int, y int) int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.SetRGBA(x, y, color.RGBA{R: uint8(level(x, y)), G: uint8(level(x, y)), B: uint8(level(x, y)), A: 255})
+		}
+	}
+
+	return img
+}
+
+func TestComputePerceptualHash(t *testing.T) {
+	tests := []struct {
+		name   string
+		source *image.RGBA
+		want   string
+	}{
+		{name: "solid color", source: newGrayImage(90, 80, func(x int, y int) int { return 128 }), want: "0000000000000000"},
+		{name: "brighter to the right", source: newGrayImage(90, 80, func(x int, y int) int { return x * 2 }), want: "0000000000000000"},
+		{name: "darker to the right", source: newGrayImage(90, 80, func(x int, y int) int { return 255 - x*2 }), want: "ffffffffffffffff"},
+		{
+			name: "darker to the right in the top half",
+			source: newGrayImage(90, 80, func(x int, y int) int {
+				if y < 40 {
+					return 255 - x*2
+				}
+				return x * 2
+			}),
+			want: "ffffffff00000000",
+		},
+		{name: "same hash once downscaled", source: newGrayImage(9, 8, func(x int, y int) int { return 255 - x*20 }), want: "ffffffffffffffff"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if hash := computePerceptualHash(test.source); hash != test.want {
+				t.Errorf("hash = %s, want %s", hash, test.want)
+			}
+		})
+	}
+}
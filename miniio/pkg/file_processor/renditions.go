@@ -0,0 +1,172 @@
+package file_processor
+
+import (
+	file_models "ImageUploadMiniIo/pkg/file_processor/models"
+	chunk_models "ImageUploadMiniIo/pkg/image_chunks/models"
+	"bytes"
+	"fmt"
+	"image"
+	"image/draw"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Sizes of the longest edge of the renditions generated when none are configured.
+var defaultRenditionSizes = []int{128, 512, 1024}
+
+// Function to get the sizes of the longest edge of the renditions, from the comma separated environment variable.
+// Renditions are turned off by setting the variable to "none".
+func getRenditionSizes() ([]int, error) {
+	// Loading the environment variables.
+	renditionSizesString := strings.TrimSpace(os.Getenv("RENDITION_SIZES"))
+	if renditionSizesString == "" {
+		return defaultRenditionSizes, nil
+	}
+	if renditionSizesString == "none" {
+		return nil, nil
+	}
+
+	var renditionSizes []int
+	for _, sizeString := range strings.Split(renditionSizesString, ",") {
+		size, err := strconv.Atoi(strings.TrimSpace(sizeString))
+		if err != nil || size <= 0 {
+			return nil, fmt.Errorf("invalid rendition size \"%s\"", sizeString)
+		}
+		renditionSizes = append(renditionSizes, size)
+	}
+	sort.Ints(renditionSizes)
+
+	return renditionSizes, nil
+}
+
+// Function to check whether the image of a particular content type can be decoded, for the steps working on its pixels.
+func isDecodingSupported(contentType string) bool {
+	return contentType == "image/jpeg" || contentType == "image/png" || contentType == "image/gif"
+}
+
+// Function to decode the compiled image of a particular session into RGBA pixels.
+// Only the first frame of an animated gif is decoded.
+func decodeSessionImage(sessionData *chunk_models.SessionData) (*image.RGBA, error) {
+	file, err := openSessionFile(sessionData)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	img, _, err := image.Decode(file)
+	if err != nil {
+		return nil, fmt.Errorf("%w: image could not be decoded: %s", ErrFileRejected, err.Error())
+	}
+
+	// Convert the image once, so that every step works on the same pixels.
+	bounds := img.Bounds()
+	rgbaImage := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(rgbaImage, rgbaImage.Bounds(), img, bounds.Min, draw.Src)
+
+	return rgbaImage, nil
+}
+
+// Function to generate the renditions of a decoded image, preserving its aspect ratio.
+// Images are never upscaled, so no rendition is generated for a size larger than the image itself.
+func generateRenditions(source *image.RGBA, contentType string) ([]*file_models.Rendition, error) {
+	renditionSizes, err := getRenditionSizes()
+	if err != nil {
+		return nil, err
+	}
+
+	bounds := source.Bounds()
+	renditions := make([]*file_models.Rendition, 0, len(renditionSizes))
+	for _, size := range renditionSizes {
+		width, height := fitWithin(bounds.Dx(), bounds.Dy(), size)
+		if width >= bounds.Dx() && height >= bounds.Dy() {
+			break
+		}
+
+		rendition, err := encodeRendition(resizeImage(source, width, height), contentType)
+		if err != nil {
+			return nil, err
+		}
+		rendition.Size = size
+		renditions = append(renditions, rendition)
+	}
+
+	return renditions, nil
+}
+
+// Function to get the dimensions of an image scaled so that its longest edge is the given size.
+func fitWithin(width int, height int, size int) (int, int) {
+	if width >= height {
+		return size, max(1, height*size/width)
+	}
+
+	return max(1, width*size/height), size
+}
+
+// Function to resize an image by averaging the source pixels covered by every destination pixel.
+// Meant for downscaling, where it avoids the aliasing of nearest neighbour sampling.
+func resizeImage(source *image.RGBA, width int, height int) *image.RGBA {
+	destination := image.NewRGBA(image.Rect(0, 0, width, height))
+	sourceWidth, sourceHeight := source.Bounds().Dx(), source.Bounds().Dy()
+
+	for y := 0; y < height; y++ {
+		y0 := y * sourceHeight / height
+		y1 := max(y0+1, (y+1)*sourceHeight/height)
+
+		for x := 0; x < width; x++ {
+			x0 := x * sourceWidth / width
+			x1 := max(x0+1, (x+1)*sourceWidth/width)
+
+			// Sum the premultiplied channels of the covered source pixels.
+			var r, g, b, a, count uint64
+			for sy := y0; sy < y1; sy++ {
+				offset := source.PixOffset(x0, sy)
+				for sx := x0; sx < x1; sx++ {
+					r += uint64(source.Pix[offset])
+					g += uint64(source.Pix[offset+1])
+					b += uint64(source.Pix[offset+2])
+					a += uint64(source.Pix[offset+3])
+					offset += 4
+					count++
+				}
+			}
+
+			offset := destination.PixOffset(x, y)
+			destination.Pix[offset] = uint8(r / count)
+			destination.Pix[offset+1] = uint8(g / count)
+			destination.Pix[offset+2] = uint8(b / count)
+			destination.Pix[offset+3] = uint8(a / count)
+		}
+	}
+
+	return destination
+}
+
+// Function to encode a rendition, as jpeg for jpeg images and as png for the others, which may carry transparency.
+func encodeRendition(img *image.RGBA, contentType string) (*file_models.Rendition, error) {
+	var rendition file_models.Rendition
+	rendition.Width = img.Bounds().Dx()
+	rendition.Height = img.Bounds().Dy()
+
+	var buffer bytes.Buffer
+	var err error
+	if contentType == "image/jpeg" {
+		rendition.ContentType = "image/jpeg"
+		rendition.Extension = "jpg"
+		err = jpeg.Encode(&buffer, img, &jpeg.Options{Quality: 85})
+	} else {
+		rendition.ContentType = "image/png"
+		rendition.Extension = "png"
+		err = png.Encode(&buffer, img)
+	}
+	if err != nil {
+		return nil, err
+	}
+	rendition.Data = buffer.Bytes()
+
+	return &rendition, nil
+}
@@ -0,0 +1,65 @@
+package file_processor
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestGetRenditionSizes(t *testing.T) {
+	tests := []struct {
+		name           string
+		renditionSizes string
+		want           []int
+		wantErr        bool
+	}{
+		{name: "default", renditionSizes: "", want: defaultRenditionSizes},
+		{name: "turned off", renditionSizes: "none", want: nil},
+		{name: "sorted", renditionSizes: "1024, 64,256", want: []int{64, 256, 1024}},
+		{name: "zero", renditionSizes: "0", wantErr: true},
+		{name: "not a number", renditionSizes: "small", wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Setenv("RENDITION_SIZES", test.renditionSizes)
+
+			renditionSizes, err := getRenditionSizes()
+			if test.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got %v", renditionSizes)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !slices.Equal(renditionSizes, test.want) {
+				t.Errorf("rendition sizes = %v, want %v", renditionSizes, test.want)
+			}
+		})
+	}
+}
+
+func TestFitWithin(t *testing.T) {
+	tests := []struct {
+		name       string
+		width      int
+		height     int
+		size       int
+		wantWidth  int
+		wantHeight int
+	}{
+		{name: "landscape", width: 4000, height: 3000, size: 1024, wantWidth: 1024, wantHeight: 768},
+		{name: "portrait", width: 3000, height: 4000, size: 1024, wantWidth: 768, wantHeight: 1024},
+		{name: "thin kept one pixel high", width: 10000, height: 1, size: 128, wantWidth: 128, wantHeight: 1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			width, height := fitWithin(test.width, test.height, test.size)
+			if width != test.wantWidth || height != test.wantHeight {
+				t.Errorf("size = %dx%d, want %dx%d", width, height, test.wantWidth, test.wantHeight)
+			}
+		})
+	}
+}
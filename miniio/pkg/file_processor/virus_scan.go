@@ -0,0 +1,167 @@
+package file_processor
+
+import (
+	file_models "ImageUploadMiniIo/pkg/file_processor/models"
+	chunk_models "ImageUploadMiniIo/pkg/image_chunks/models"
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Size of the chunks the file is streamed to the clamd daemon in, well below its default stream limit.
+const clamdChunkSize = 64 * 1024
+
+// Error returned when the clamd daemon finds a virus in the compiled file.
+// It wraps ErrFileRejected, and carries the verdict so that the file can be quarantined along with it.
+type InfectedFileError struct {
+	ScanResult *file_models.ScanResult
+}
+
+func (err *InfectedFileError) Error() string {
+	return fmt.Sprintf("%s: file is infected with \"%s\"", ErrFileRejected.Error(), err.ScanResult.Signature)
+}
+
+func (err *InfectedFileError) Unwrap() error {
+	return ErrFileRejected
+}
+
+// Function to get the network and address of the clamd daemon, empty when scanning is turned off.
+// The address is either "tcp://host:port", "unix:///path/to/clamd.sock" or a plain "host:port".
+func getClamdAddress() (string, string) {
+	// Loading the environment variables.
+	clamdAddress := strings.TrimSpace(os.Getenv("CLAMD_ADDRESS"))
+	if clamdAddress == "" {
+		return "", ""
+	}
+
+	if path, ok := strings.CutPrefix(clamdAddress, "unix://"); ok {
+		return "unix", path
+	}
+
+	return "tcp", strings.TrimPrefix(clamdAddress, "tcp://")
+}
+
+// Function to get the time allowed for a scan, including the time taken to stream the file.
+func getClamdTimeout() time.Duration {
+	// Loading the environment variables.
+	timeoutSeconds, err := strconv.Atoi(os.Getenv("CLAMD_TIMEOUT"))
+	if err != nil || timeoutSeconds <= 0 {
+		return 60 * time.Second
+	}
+
+	return time.Duration(timeoutSeconds) * time.Second
+}
+
+// Function to check whether infected files should be quarantined, rather than only rejected.
+func IsQuarantineEnabled() bool {
+	// Loading the environment variables.
+	return os.Getenv("CLAMD_INFECTED_ACTION") == "quarantine"
+}
+
+// Function to stream a file to the clamd daemon with the INSTREAM command and read back its reply.
+// The file is sent as chunks prefixed with their length, and a zero length chunk ends the stream.
+func scanWithClamd(network string, address string, reader io.Reader) (string, error) {
+	connection, err := net.DialTimeout(network, address, 10*time.Second)
+	if err != nil {
+		return "", err
+	}
+	defer connection.Close()
+
+	err = connection.SetDeadline(time.Now().Add(getClamdTimeout()))
+	if err != nil {
+		return "", err
+	}
+
+	// The "z" prefix makes the daemon expect and send null terminated messages.
+	if _, err := connection.Write([]byte("zINSTREAM\x00")); err != nil {
+		return "", err
+	}
+
+	chunk := make([]byte, clamdChunkSize)
+	lengthBytes := make([]byte, 4)
+	for {
+		n, readErr := reader.Read(chunk)
+		if n > 0 {
+			binary.BigEndian.PutUint32(lengthBytes, uint32(n))
+			if _, err := connection.Write(lengthBytes); err != nil {
+				return "", err
+			}
+			if _, err := connection.Write(chunk[:n]); err != nil {
+				return "", err
+			}
+		}
+		if readErr == io.EOF {
+			break
+		} else if readErr != nil {
+			return "", readErr
+		}
+	}
+	if _, err := connection.Write([]byte{0, 0, 0, 0}); err != nil {
+		return "", err
+	}
+
+	reply, err := bufio.NewReader(connection).ReadString(0)
+	if err != nil && err != io.EOF {
+		return "", err
+	}
+
+	return strings.TrimSpace(strings.TrimRight(reply, "\x00")), nil
+}
+
+// Function to parse the reply of the clamd daemon into the scan result.
+// The reply is "stream: OK" for a clean file and "stream: <signature> FOUND" for an infected one.
+func parseClamdReply(reply string) (*file_models.ScanResult, error) {
+	var scanResult file_models.ScanResult
+	scanResult.Scanner = "clamd"
+	scanResult.ScanTime = time.Now()
+
+	verdict := strings.TrimSpace(strings.TrimPrefix(reply, "stream:"))
+	switch {
+	case verdict == "OK":
+		scanResult.Status = file_models.ScanClean
+	case strings.HasSuffix(verdict, " FOUND"):
+		scanResult.Status = file_models.ScanInfected
+		scanResult.Signature = strings.TrimSuffix(verdict, " FOUND")
+	default:
+		return nil, fmt.Errorf("clamd scan failed: %s", reply)
+	}
+
+	return &scanResult, nil
+}
+
+// Function to scan the compiled file of a particular session for viruses with the clamd daemon.
+// Returns nil when scanning is turned off. If the daemon cannot be reached the error does not reject
+// the file, so that the job can be run again once the daemon is back.
+func scanSessionFile(sessionData *chunk_models.SessionData) (*file_models.ScanResult, error) {
+	network, address := getClamdAddress()
+	if address == "" {
+		return nil, nil
+	}
+
+	file, err := openSessionFile(sessionData)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	reply, err := scanWithClamd(network, address, file)
+	if err != nil {
+		return nil, fmt.Errorf("clamd scan failed: %s", err.Error())
+	}
+
+	scanResult, err := parseClamdReply(reply)
+	if err != nil {
+		return nil, err
+	}
+	if scanResult.Status == file_models.ScanInfected {
+		return nil, &InfectedFileError{ScanResult: scanResult}
+	}
+
+	return scanResult, nil
+}
@@ -0,0 +1,263 @@
+package file_processor
+
+import (
+	file_models "ImageUploadMiniIo/pkg/file_processor/models"
+	chunk_models "ImageUploadMiniIo/pkg/image_chunks/models"
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Content the fake clamd daemon reports as infected.
+const eicarMarker = "EICAR-STANDARD-ANTIVIRUS-TEST-FILE"
+
+// Stream received by the fake clamd daemon from a single connection.
+type clamdStream struct {
+	command    string
+	chunkSizes []int
+	data       []byte
+	terminated bool
+}
+
+// Function to start a fake clamd daemon answering the INSTREAM command, reporting the streams holding the EICAR
+// marker as infected. The streams it received are sent on the returned channel.
+func startFakeClamd(t *testing.T) (string, <-chan *clamdStream) {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("could not listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	streams := make(chan *clamdStream, 16)
+	go func() {
+		for {
+			connection, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			streams <- serveFakeClamd(connection)
+		}
+	}()
+
+	return listener.Addr().String(), streams
+}
+
+// Function to read a single INSTREAM command from a connection and reply to it.
+func serveFakeClamd(connection net.Conn) *clamdStream {
+	defer connection.Close()
+
+	var stream clamdStream
+	reader := bufio.NewReader(connection)
+
+	command, err := reader.ReadString(0)
+	if err != nil {
+		return &stream
+	}
+	stream.command = command
+
+	lengthBytes := make([]byte, 4)
+	for {
+		if _, err := io.ReadFull(reader, lengthBytes); err != nil {
+			return &stream
+		}
+		length := binary.BigEndian.Uint32(lengthBytes)
+		if length == 0 {
+			stream.terminated = true
+			break
+		}
+
+		chunk := make([]byte, length)
+		if _, err := io.ReadFull(reader, chunk); err != nil {
+			return &stream
+		}
+		stream.chunkSizes = append(stream.chunkSizes, int(length))
+		stream.data = append(stream.data, chunk...)
+	}
+
+	if bytes.Contains(stream.data, []byte(eicarMarker)) {
+		connection.Write([]byte("stream: Eicar-Test FOUND\x00"))
+	} else {
+		connection.Write([]byte("stream: OK\x00"))
+	}
+
+	return &stream
+}
+
+func TestParseClamdReply(t *testing.T) {
+	tests := []struct {
+		name          string
+		reply         string
+		wantStatus    string
+		wantSignature string
+		wantErr       bool
+	}{
+		{name: "clean", reply: "stream: OK", wantStatus: file_models.ScanClean},
+		{name: "clean without space", reply: "stream:OK", wantStatus: file_models.ScanClean},
+		{name: "infected", reply: "stream: Eicar-Test FOUND", wantStatus: file_models.ScanInfected, wantSignature: "Eicar-Test"},
+		{name: "infected with spaces in signature", reply: "stream: Win.Test.EICAR_HDB-1 Extra FOUND", wantStatus: file_models.ScanInfected, wantSignature: "Win.Test.EICAR_HDB-1 Extra"},
+		{name: "size limit exceeded", reply: "INSTREAM size limit exceeded. ERROR", wantErr: true},
+		{name: "empty", reply: "", wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			scanResult, err := parseClamdReply(test.reply)
+			if test.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got %+v", scanResult)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if scanResult.Scanner != "clamd" {
+				t.Errorf("scanner = %q, want %q", scanResult.Scanner, "clamd")
+			}
+			if scanResult.Status != test.wantStatus {
+				t.Errorf("status = %q, want %q", scanResult.Status, test.wantStatus)
+			}
+			if scanResult.Signature != test.wantSignature {
+				t.Errorf("signature = %q, want %q", scanResult.Signature, test.wantSignature)
+			}
+		})
+	}
+}
+
+func TestScanWithClamd(t *testing.T) {
+	address, streams := startFakeClamd(t)
+
+	tests := []struct {
+		name           string
+		data           []byte
+		wantReply      string
+		wantChunkSizes []int
+	}{
+		{name: "empty file", data: nil, wantReply: "stream: OK", wantChunkSizes: nil},
+		{name: "small clean file", data: []byte("hello"), wantReply: "stream: OK", wantChunkSizes: []int{5}},
+		{name: "infected file", data: []byte("X5O!P%@AP[4\\PZX54(P^)7CC)7}$" + eicarMarker + "!$H+H*"), wantReply: "stream: Eicar-Test FOUND", wantChunkSizes: []int{68}},
+		{name: "exactly one chunk", data: bytes.Repeat([]byte{1}, clamdChunkSize), wantReply: "stream: OK", wantChunkSizes: []int{clamdChunkSize}},
+		{name: "several chunks", data: bytes.Repeat([]byte{2}, 2*clamdChunkSize+10), wantReply: "stream: OK", wantChunkSizes: []int{clamdChunkSize, clamdChunkSize, 10}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			reply, err := scanWithClamd("tcp", address, bytes.NewReader(test.data))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if reply != test.wantReply {
+				t.Errorf("reply = %q, want %q", reply, test.wantReply)
+			}
+
+			stream := <-streams
+			if stream.command != "zINSTREAM\x00" {
+				t.Errorf("command = %q, want %q", stream.command, "zINSTREAM\x00")
+			}
+			if !stream.terminated {
+				t.Errorf("stream was not ended with a zero length chunk")
+			}
+			if len(stream.chunkSizes) != len(test.wantChunkSizes) {
+				t.Fatalf("chunk sizes = %v, want %v", stream.chunkSizes, test.wantChunkSizes)
+			}
+			for i := range stream.chunkSizes {
+				if stream.chunkSizes[i] != test.wantChunkSizes[i] {
+					t.Errorf("chunk sizes = %v, want %v", stream.chunkSizes, test.wantChunkSizes)
+					break
+				}
+			}
+			if !bytes.Equal(stream.data, test.data) {
+				t.Errorf("daemon received %d bytes, want the %d bytes sent", len(stream.data), len(test.data))
+			}
+		})
+	}
+}
+
+func TestScanWithClamdUnreachable(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("could not listen: %v", err)
+	}
+	address := listener.Addr().String()
+	listener.Close()
+
+	_, err = scanWithClamd("tcp", address, strings.NewReader("hello"))
+	if err == nil {
+		t.Fatalf("expected an error for an unreachable daemon")
+	}
+}
+
+func TestScanSessionFile(t *testing.T) {
+	address, streams := startFakeClamd(t)
+	t.Setenv("FOLDER_PERM_PATH", t.TempDir())
+
+	tests := []struct {
+		name          string
+		clamdAddress  string
+		data          string
+		wantStatus    string
+		wantInfected  bool
+		wantSignature string
+	}{
+		{name: "scanning turned off", clamdAddress: "", data: "hello"},
+		{name: "clean file", clamdAddress: "tcp://" + address, data: "hello", wantStatus: file_models.ScanClean},
+		{name: "infected file", clamdAddress: address, data: eicarMarker, wantInfected: true, wantSignature: "Eicar-Test"},
+	}
+
+	for i, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Setenv("CLAMD_ADDRESS", test.clamdAddress)
+
+			var sessionData chunk_models.SessionData
+			sessionData.SessionId = "session-" + string(rune('a'+i))
+			sessionData.FileType = "jpg"
+			filePath := filepath.Join(os.Getenv("FOLDER_PERM_PATH"), sessionData.SessionId, sessionData.SessionId+".jpg")
+			if err := os.MkdirAll(filepath.Dir(filePath), 0o755); err != nil {
+				t.Fatalf("could not create folder: %v", err)
+			}
+			if err := os.WriteFile(filePath, []byte(test.data), 0o644); err != nil {
+				t.Fatalf("could not write file: %v", err)
+			}
+
+			scanResult, err := scanSessionFile(&sessionData)
+			if test.clamdAddress != "" {
+				<-streams
+			}
+
+			if test.wantInfected {
+				var infectedErr *InfectedFileError
+				if !errors.As(err, &infectedErr) {
+					t.Fatalf("error = %v, want an InfectedFileError", err)
+				}
+				if !errors.Is(err, ErrFileRejected) {
+					t.Errorf("error %v does not wrap ErrFileRejected", err)
+				}
+				if infectedErr.ScanResult.Signature != test.wantSignature {
+					t.Errorf("signature = %q, want %q", infectedErr.ScanResult.Signature, test.wantSignature)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if test.wantStatus == "" {
+				if scanResult != nil {
+					t.Errorf("scan result = %+v, want none", scanResult)
+				}
+				return
+			}
+			if scanResult == nil || scanResult.Status != test.wantStatus {
+				t.Errorf("scan result = %+v, want status %q", scanResult, test.wantStatus)
+			}
+		})
+	}
+}
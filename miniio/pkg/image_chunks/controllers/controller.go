@@ -1,9 +1,15 @@
 package controllers
 
 import (
-	"ImageUploadMiniIo/pkg/chunk_manager"
 	chunk_helpers "ImageUploadMiniIo/pkg/image_chunks/helpers"
-	miniio "ImageUploadMiniIo/pkg/mini_io"
+	chunk_models "ImageUploadMiniIo/pkg/image_chunks/models"
+	"ImageUploadMiniIo/pkg/job_manager"
+	job_models "ImageUploadMiniIo/pkg/job_manager/models"
+	"ImageUploadMiniIo/pkg/progress"
+	progress_models "ImageUploadMiniIo/pkg/progress/models"
+	"ImageUploadMiniIo/pkg/webhook"
+	webhook_models "ImageUploadMiniIo/pkg/webhook/models"
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -26,10 +32,18 @@ func UploadChunks() gin.HandlerFunc {
 			return
 		}
 
+		// Get the client request data, parsed once from the form fields and headers sent along with the chunk.
+		requestData, err := chunk_helpers.GetChunkDetails(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Bad request.", "error_details": err.Error()})
+			c.Abort()
+			return
+		}
+
 		// Check whether the session id is empty or not.
 		// If yes creates a new session and get the cookie which is further added in the response from the server side.
 		if sessionId == "" {
-			cookie, err := chunk_helpers.CreateCookie(c)
+			cookie, err := chunk_helpers.CreateCookie(c, requestData)
 			if err != nil {
 				c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error", "error_details": err.Error()})
 				c.Abort()
@@ -38,42 +52,81 @@ func UploadChunks() gin.HandlerFunc {
 
 			http.SetCookie(c.Writer, cookie)
 
-			// Retrieving the session id from the created cookie.
-			sessionId, err = c.Cookie(cookie.Name)
+			// Retrieving the session id from the created cookie, as the request itself does not carry it yet.
+			sessionId = cookie.Value
+		}
+
+		// Upload the chunk and check the status if it has succeeded or failed.
+		chunkNumber, uploadErr := chunk_helpers.UploadChunkHelper(c, sessionId, requestData)
+		if uploadErr != nil && chunkNumber == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Bad request.", "error_details": uploadErr.Error()})
+			c.Abort()
+			return
+		}
+
+		// If upload is unsuccessful, update the failed list in the session store.
+		// If the chunk has failed more times than allowed, abandon the whole session.
+		// If upload is successful, remove the chunk from the failed list in case it was a retry.
+		if uploadErr != nil {
+			failedCount, err := chunk_helpers.UpdateFailedList(sessionId, *chunkNumber)
 			if err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error", "error_details": err.Error()})
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error.", "error_details": err.Error()})
 				c.Abort()
 				return
 			}
-		}
+			progress.Publish(progress_models.EventChunkFailed, sessionId, map[string]any{
+				"chunk_number": *chunkNumber,
+				"failed_count": failedCount,
+				"error":        uploadErr.Error(),
+			})
 
-		// Update the send list in the redis.
-		receivedIdsSet, err := chunk_helpers.UpdateReceivedIdSet(c, sessionId)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error.", "error_details": err.Error()})
+			if failedCount > chunk_helpers.GetMaxChunkRetries() {
+				response := gin.H{"error": "Chunk has exceeded the maximum number of retries.", "chunk_number": *chunkNumber}
+				eventData := map[string]any{
+					"chunk_number": *chunkNumber,
+					"error":        uploadErr.Error(),
+					"abandoned":    true,
+				}
+				progress.Publish(progress_models.EventFailed, sessionId, eventData)
+				webhook.Send(webhook_models.EventUploadFailed, sessionId, eventData)
+
+				// Delete redis row item, temp folder and perm folder for that session id.
+				errors := chunk_helpers.DeleteAllForSession(sessionId)
+				if len(errors) > 0 {
+					response["error_list"] = errors
+				}
+
+				c.JSON(http.StatusUnprocessableEntity, response)
+				c.Abort()
+				return
+			}
+
+			// Report the rejected chunk back to the client, so that it can be re-sent.
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Chunk upload failed, re-send the chunk.", "error_details": uploadErr.Error(), "chunk_number": *chunkNumber})
 			c.Abort()
 			return
 		}
-
-		// Upload the chunk and check the status if it has succeeded or failed.
-		chunkNumber, err := chunk_helpers.UploadChunkHelper(c, sessionId)
-
-		// If upload is unsuccessful, update the redis status unsuccessful list.
+		// Only once the chunk has been saved, mark it as received so that it counts towards the compilation.
+		receivedIdsSet, err := chunk_helpers.UpdateReceivedIdSet(sessionId, requestData)
 		if err != nil {
-			chunk_helpers.UpdateRedisFailedList(c, sessionId, *chunkNumber)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error.", "error_details": err.Error()})
+			c.Abort()
+			return
 		}
-
-		// Check first whether all the chunks have been forwared or not.
-		// If yes, check whether all the chunks have received or not.
-		// If no, then get the all the chunks which has failed to upload & send those chunk numbers as a response to client.
-		// Get the client request data.
-		requestData, err := chunk_helpers.GetChunkDetails(c)
+		err = chunk_helpers.ClearFailedChunk(sessionId, *chunkNumber)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error.", "error_details": err.Error()})
 			c.Abort()
 			return
 		}
+		progress.Publish(progress_models.EventChunkReceived, sessionId, map[string]any{
+			"chunk_number":   *chunkNumber,
+			"received_count": receivedIdsSet.Cardinality(),
+		})
 
+		// Check first whether all the chunks have been forwared or not.
+		// If yes, check whether all the chunks have received or not.
+		// If no, then get the all the chunks which has failed to upload & send those chunk numbers as a response to client.
 		// Get the total chunk number saved in redis for the particular session.
 		totalChunksPointer, err := chunk_helpers.GetTotalChunks(sessionId)
 		if err != nil {
@@ -86,22 +139,25 @@ func UploadChunks() gin.HandlerFunc {
 		totalChunks := *totalChunksPointer
 		if requestData.CompileStatus && receivedIdsSet.Cardinality() == totalChunks {
 			// Check whether any of chunks have failed or not.
-			// If yes then end the process for the paritcular session id or else go with compiling the chunks.
+			// If yes then report the failed chunks back to the client or else go with compiling the chunks.
+			// The session is kept alive so that the client re-sends only the failed chunk numbers.
 			failedList, err := chunk_helpers.CheckFailStatus(sessionId)
 			if failedList != nil {
-				response := gin.H{"error": "Few chunks have failed.", "failed_chunk_list": failedList}
-
-				// Delete redis row item, temp folder and perm folder for that session id.
-				errors := chunk_helpers.DeleteAllForSession(sessionId)
-				if len(errors) > 0 {
-					response["error_list"] = errors
-				}
+				response := gin.H{"error": "Few chunks have failed, re-send the failed chunks.", "failed_chunk_list": failedList}
+				webhook.Send(webhook_models.EventUploadFailed, sessionId, map[string]any{
+					"failed_chunk_list": failedList,
+					"abandoned":         false,
+				})
 
 				c.JSON(http.StatusPartialContent, response)
 				c.Abort()
 				return
 			} else if err != nil {
 				response := gin.H{"error": "Internal server error.", "error_details": err.Error()}
+				progress.Publish(progress_models.EventFailed, sessionId, map[string]any{
+					"error":     err.Error(),
+					"abandoned": true,
+				})
 
 				// Delete redis row item, temp folder and perm folder for that session id.
 				errors := chunk_helpers.DeleteAllForSession(sessionId)
@@ -114,33 +170,192 @@ func UploadChunks() gin.HandlerFunc {
 				return
 			}
 
-			// If all the chunks have been successfully uploaded, call to chunk manager to initiate the process
-			// of merging the chunks and saving it as a whole file.
-			// And also, send in the server response file uploaded successfully.
-			err = chunk_manager.CompileChunks(c, sessionId)
-			if err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error.", "error_details": err.Error()})
-				c.Abort()
-				return
-			}
+			// If all the chunks have been successfully uploaded, enqueue the job merging the chunks, saving it
+			// as a whole file and storing it in the mini-io bucket.
+			// And also, send in the server response where the status of the job can be polled.
+			c.JSON(enqueueFinalizeJob(sessionId, job_models.JobSourceChunks))
+		}
+	}
+}
 
-			// Run the mini-io and transfer the files into s3 buckets.
-			err = miniio.UploadSessionFilesToMiniIoBucket(sessionId)
-			if err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error.", "error_details": err.Error()})
-				c.Abort()
-				return
-			}
+// Function to enqueue the job finalizing the upload of a particular session.
+// Returns the status and body of the server response.
+func enqueueFinalizeJob(sessionId string, source string) (int, gin.H) {
+	job, err := job_manager.EnqueueFinalizeJob(sessionId, source)
+	if errors.Is(err, job_manager.ErrFinalizeInProgress) {
+		// Another request has completed the upload at the same time, report the job it is finalizing.
+		return http.StatusAccepted, gin.H{"message": "File is already being finalized.", "job": job, "job_status_url": "/api/v1/uploads/" + sessionId + "/job"}
+	} else if err != nil {
+		return http.StatusInternalServerError, gin.H{"error": "Internal server error.", "error_details": err.Error()}
+	}
 
-			// Delete redis row item, temp folder and perm folder for that session id.
-			errors := chunk_helpers.DeleteAllForSession(sessionId)
-			if len(errors) > 0 {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error.", "error_list": errors})
-				c.Abort()
-				return
-			}
+	return http.StatusAccepted, gin.H{"message": "File is being finalized.", "job": job, "job_status_url": "/api/v1/uploads/" + sessionId + "/job"}
+}
+
+// Upload session creation controller.
+func CreateUploadSession() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Create the session from the file details passed in the client request.
+		sessionData, err := chunk_helpers.CreateSession(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Bad request.", "error_details": err.Error()})
+			c.Abort()
+			return
+		}
 
-			c.JSON(http.StatusOK, gin.H{"message": "File successfully uploaded."})
+		// Send the session id, chunk plan and expiry time for the client to start uploading the chunks.
+		chunkPlan, err := chunk_helpers.GetChunkPlan(sessionData.FileDetails)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error.", "error_details": err.Error()})
+			c.Abort()
+			return
 		}
+
+		var response chunk_models.UploadSessionResponse
+		response.SessionId = sessionData.SessionId
+		response.ChunkPlan = *chunkPlan
+		response.ExpiryTime = sessionData.ExpiryTime
+
+		c.JSON(http.StatusCreated, response)
+	}
+}
+
+// Upload status controller.
+func GetUploadStatus() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Get the session data kept in the redis for the session id passed in the path.
+		sessionData, ttl, err := chunk_helpers.GetSessionData(c.Param("session_id"))
+		if errors.Is(err, chunk_helpers.ErrSessionNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Session not found.", "error_details": err.Error()})
+			c.Abort()
+			return
+		} else if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error.", "error_details": err.Error()})
+			c.Abort()
+			return
+		}
+
+		c.JSON(http.StatusOK, chunk_helpers.GetUploadStatus(sessionData, ttl))
+	}
+}
+
+// Upload abort controller.
+func AbortUpload() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		sessionId := c.Param("session_id")
+
+		// Get what is held by the session before cleaning it up.
+		sessionResources, err := chunk_helpers.GetSessionResources(sessionId)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error.", "error_details": err.Error()})
+			c.Abort()
+			return
+		}
+		if !sessionResources.RedisEntry && !sessionResources.TempFolder && !sessionResources.PermFolder {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Session not found."})
+			c.Abort()
+			return
+		}
+
+		// Delete redis row item, temp folder and perm folder for that session id.
+		errors := chunk_helpers.DeleteAllForSession(sessionId)
+		if len(errors) > 0 {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error.", "error_list": errors, "cleaned_up": sessionResources})
+			c.Abort()
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Upload session aborted.", "cleaned_up": sessionResources})
+	}
+}
+
+// Byte range upload controller.
+func UploadRange() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		sessionId := c.Param("session_id")
+
+		// Parse the byte range the request body should be written at.
+		byteRange, total, err := chunk_helpers.ParseContentRange(c.GetHeader("Content-Range"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Bad request.", "error_details": err.Error()})
+			c.Abort()
+			return
+		}
+
+		// Get the session data kept in the redis for the session id passed in the path.
+		sessionData, _, err := chunk_helpers.GetSessionData(sessionId)
+		if errors.Is(err, chunk_helpers.ErrSessionNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Session not found.", "error_details": err.Error()})
+			c.Abort()
+			return
+		} else if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error.", "error_details": err.Error()})
+			c.Abort()
+			return
+		}
+
+		// Validate the range against the session and write it at its position.
+		err = chunk_helpers.ValidateRange(sessionData, total)
+		if err != nil {
+			c.JSON(http.StatusRequestedRangeNotSatisfiable, gin.H{"error": "Range not satisfiable.", "error_details": err.Error()})
+			c.Abort()
+			return
+		}
+
+		_, err = chunk_helpers.SaveRange(c, sessionData, byteRange, nil)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Range upload failed, re-send the range.", "error_details": err.Error()})
+			c.Abort()
+			return
+		}
+
+		// Update the received ranges in the redis.
+		sessionData, err = chunk_helpers.UpdateReceivedRanges(sessionId, *byteRange, total)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error.", "error_details": err.Error()})
+			c.Abort()
+			return
+		}
+
+		progress.Publish(progress_models.EventChunkReceived, sessionId, map[string]any{
+			"range":          byteRange,
+			"received_bytes": chunk_helpers.GetReceivedBytes(sessionData),
+			"total_bytes":    sessionData.TotalBytes,
+		})
+
+		// If all the bytes have not been received yet, send the received ranges back to the client.
+		if !chunk_helpers.IsRangeUploadComplete(sessionData) {
+			c.JSON(http.StatusOK, gin.H{
+				"message":         "Range received.",
+				"received_ranges": sessionData.ReceivedRanges,
+				"received_bytes":  chunk_helpers.GetReceivedBytes(sessionData),
+				"total_bytes":     sessionData.TotalBytes,
+			})
+			return
+		}
+
+		// If all the bytes have been received, enqueue the job moving the assembled file to the permanent location
+		// and storing it in the mini-io bucket.
+		c.JSON(enqueueFinalizeJob(sessionId, job_models.JobSourceRanges))
+	}
+}
+
+// Finalize job status controller.
+func GetFinalizeJob() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Get the finalize job kept in the redis for the session id passed in the path.
+		job, err := job_manager.GetJob(c.Param("session_id"))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error.", "error_details": err.Error()})
+			c.Abort()
+			return
+		}
+		if job == nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Job not found."})
+			c.Abort()
+			return
+		}
+
+		c.JSON(http.StatusOK, job)
 	}
 }
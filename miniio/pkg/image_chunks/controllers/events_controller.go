@@ -0,0 +1,82 @@
+package controllers
+
+import (
+	chunk_helpers "ImageUploadMiniIo/pkg/image_chunks/helpers"
+	"ImageUploadMiniIo/pkg/job_manager"
+	job_models "ImageUploadMiniIo/pkg/job_manager/models"
+	"ImageUploadMiniIo/pkg/progress"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Upload progress event stream controller.
+func StreamUploadEvents() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		sessionId := c.Param("session_id")
+
+		// Subscribe before reading the current state, so that no event is missed in between.
+		events, err := progress.Subscribe(c.Request.Context(), sessionId)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error.", "error_details": err.Error()})
+			c.Abort()
+			return
+		}
+
+		// Get the current state of the upload, either from the session or from its finalize job.
+		sessionData, ttl, err := chunk_helpers.GetSessionData(sessionId)
+		if err != nil && !errors.Is(err, chunk_helpers.ErrSessionNotFound) {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error.", "error_details": err.Error()})
+			c.Abort()
+			return
+		}
+		job, err := job_manager.GetJob(sessionId)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error.", "error_details": err.Error()})
+			c.Abort()
+			return
+		}
+		if sessionData == nil && job == nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Session not found."})
+			c.Abort()
+			return
+		}
+
+		c.Header("Cache-Control", "no-cache")
+		c.Header("X-Accel-Buffering", "no")
+
+		// Send the current state first, and end the stream right away if the job has already finished.
+		// A failed job whose session is still around can be triggered again, so its stream is kept open.
+		if sessionData != nil {
+			c.SSEvent("status", chunk_helpers.GetUploadStatus(sessionData, ttl))
+		}
+		if job != nil {
+			c.SSEvent("job", job)
+			if job.State == job_models.JobDone || (job.State == job_models.JobFailed && sessionData == nil) {
+				return
+			}
+		}
+		c.Writer.Flush()
+
+		// Keep the connection alive through the proxies while waiting for the events.
+		keepAlive := time.NewTicker(15 * time.Second)
+		defer keepAlive.Stop()
+
+		c.Stream(func(w io.Writer) bool {
+			select {
+			case event, ok := <-events:
+				if !ok {
+					return false
+				}
+				c.SSEvent(event.EventType, event)
+				return !progress.IsFinal(event.EventType)
+			case <-keepAlive.C:
+				c.SSEvent("ping", time.Now())
+				return true
+			}
+		})
+	}
+}
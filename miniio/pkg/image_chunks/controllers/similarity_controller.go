@@ -0,0 +1,87 @@
+package controllers
+
+import (
+	"ImageUploadMiniIo/pkg/similarity"
+	similarity_models "ImageUploadMiniIo/pkg/similarity/models"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Hamming distance and number of matches used when the client does not pass them.
+const (
+	defaultSimilarityDistance = 10
+	defaultSimilarityLimit    = 50
+	maxSimilarityLimit        = 500
+)
+
+func FindSimilarImages() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Get the perceptual hash to search for, either passed directly or taken from an indexed object.
+		hash := c.Query("hash")
+		objectName := c.Query("object_name")
+		if hash == "" && objectName == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Either hash or object_name is required."})
+			c.Abort()
+			return
+		}
+		if hash == "" {
+			var err error
+			hash, err = similarity.GetObjectHash(objectName)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error.", "error_details": err.Error()})
+				c.Abort()
+				return
+			}
+			if hash == "" {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Object not indexed."})
+				c.Abort()
+				return
+			}
+		}
+
+		// Get the maximum distance and number of matches.
+		maxDistance, err := strconv.Atoi(c.DefaultQuery("distance", strconv.Itoa(defaultSimilarityDistance)))
+		if err != nil || maxDistance < 0 || maxDistance > 64 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Distance should be a number from 0 to 64."})
+			c.Abort()
+			return
+		}
+		limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultSimilarityLimit)))
+		if err != nil || limit <= 0 || limit > maxSimilarityLimit {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Limit should be a number from 1 to " + strconv.Itoa(maxSimilarityLimit) + "."})
+			c.Abort()
+			return
+		}
+
+		// The searched object itself is always a match, so it is left out, taking one more match to make up for it.
+		searchLimit := limit
+		if objectName != "" {
+			searchLimit++
+		}
+		matches, err := similarity.FindSimilarObjects(hash, maxDistance, searchLimit)
+		if errors.Is(err, similarity.ErrInvalidHash) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid hash.", "error_details": err.Error()})
+			c.Abort()
+			return
+		} else if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error.", "error_details": err.Error()})
+			c.Abort()
+			return
+		}
+
+		var response similarity_models.SimilarObjectsResponse
+		response.PerceptualHash = hash
+		response.MaxDistance = maxDistance
+		response.Matches = make([]similarity_models.SimilarObject, 0, len(matches))
+		for _, match := range matches {
+			if match.ObjectName != objectName && len(response.Matches) < limit {
+				response.Matches = append(response.Matches, match)
+			}
+		}
+
+		c.JSON(http.StatusOK, response)
+	}
+}
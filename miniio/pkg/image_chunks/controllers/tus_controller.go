@@ -0,0 +1,214 @@
+package controllers
+
+import (
+	chunk_helpers "ImageUploadMiniIo/pkg/image_chunks/helpers"
+	chunk_models "ImageUploadMiniIo/pkg/image_chunks/models"
+	job_models "ImageUploadMiniIo/pkg/job_manager/models"
+	"ImageUploadMiniIo/pkg/progress"
+	progress_models "ImageUploadMiniIo/pkg/progress/models"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Tus discovery controller.
+func TusOptions() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Tus-Version", chunk_helpers.TusVersion)
+		c.Header("Tus-Extension", chunk_helpers.TusExtensions)
+		c.Header("Tus-Checksum-Algorithm", chunk_helpers.TusChecksumAlgorithms)
+		if maxSize := chunk_helpers.GetTusMaxSize(); maxSize > 0 {
+			c.Header("Tus-Max-Size", strconv.FormatInt(maxSize, 10))
+		}
+
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// Tus creation controller.
+func TusCreate() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Create the session from the upload length and metadata passed in the client request.
+		sessionData, err := chunk_helpers.CreateTusSession(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Bad request.", "error_details": err.Error()})
+			c.Abort()
+			return
+		}
+
+		// The location of the upload is where the client sends the following requests to.
+		c.Header("Location", c.Request.URL.Path+"/"+sessionData.SessionId)
+
+		// An empty upload is complete from its creation, so its job is enqueued straight away.
+		if chunk_helpers.IsTusUploadComplete(sessionData) {
+			status, response := enqueueFinalizeJob(sessionData.SessionId, job_models.JobSourceRanges)
+			if status != http.StatusAccepted {
+				c.JSON(status, response)
+				c.Abort()
+				return
+			}
+		}
+
+		c.Status(http.StatusCreated)
+	}
+}
+
+// Tus offset query controller.
+func TusHead() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// The response of the offset query should never be cached.
+		c.Header("Cache-Control", "no-store")
+
+		// Get the session data kept in the redis for the session id passed in the path.
+		sessionData, _, err := chunk_helpers.GetSessionData(c.Param("session_id"))
+		if errors.Is(err, chunk_helpers.ErrSessionNotFound) {
+			c.Status(http.StatusNotFound)
+			return
+		} else if err != nil {
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+
+		c.Header("Upload-Offset", strconv.FormatInt(chunk_helpers.GetTusOffset(sessionData), 10))
+		c.Header("Upload-Length", strconv.FormatInt(sessionData.TotalBytes, 10))
+		c.Status(http.StatusOK)
+	}
+}
+
+// Tus append controller.
+func TusPatch() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		sessionId := c.Param("session_id")
+
+		// The body of an append request should be sent as an offset stream.
+		if c.ContentType() != "application/offset+octet-stream" {
+			c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": "Content type should be application/offset+octet-stream."})
+			c.Abort()
+			return
+		}
+
+		// Lock the upload before its offset is checked, so that two appends at the same offset cannot both be written.
+		unlockUpload, err := chunk_helpers.LockTusUpload(sessionId)
+		if errors.Is(err, chunk_helpers.ErrTusUploadLocked) {
+			c.JSON(http.StatusLocked, gin.H{"error": "Upload locked.", "error_details": err.Error()})
+			c.Abort()
+			return
+		} else if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error.", "error_details": err.Error()})
+			c.Abort()
+			return
+		}
+		defer unlockUpload()
+
+		// Get the session data kept in the redis for the session id passed in the path.
+		sessionData, _, err := chunk_helpers.GetSessionData(sessionId)
+		if errors.Is(err, chunk_helpers.ErrSessionNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Session not found.", "error_details": err.Error()})
+			c.Abort()
+			return
+		} else if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error.", "error_details": err.Error()})
+			c.Abort()
+			return
+		}
+
+		// Get the byte range the request body is appended at.
+		byteRange, err := chunk_helpers.GetTusPatchRange(c, sessionData)
+		if errors.Is(err, chunk_helpers.ErrTusOffsetMismatch) {
+			c.JSON(http.StatusConflict, gin.H{"error": "Offset mismatch.", "error_details": err.Error()})
+			c.Abort()
+			return
+		} else if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Bad request.", "error_details": err.Error()})
+			c.Abort()
+			return
+		}
+
+		// Get the checksum the client has sent for the body, if any.
+		bodyChecksum, err := chunk_helpers.GetTusChecksum(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Bad request.", "error_details": err.Error()})
+			c.Abort()
+			return
+		}
+
+		// Write the bytes and update the received ranges in the redis.
+		// If the body ends early, the bytes written so far are kept, so that the client can resume from them.
+		if byteRange != nil {
+			written, saveErr := chunk_helpers.SaveRange(c, sessionData, byteRange, bodyChecksum)
+			if errors.Is(saveErr, chunk_helpers.ErrBodyChecksumMismatch) {
+				c.JSON(chunk_helpers.StatusChecksumMismatch, gin.H{"error": "Checksum mismatch.", "error_details": saveErr.Error()})
+				c.Abort()
+				return
+			}
+
+			if written > 0 {
+				receivedRange := chunk_models.ByteRange{Start: byteRange.Start, End: byteRange.Start + written - 1}
+				sessionData, err = chunk_helpers.UpdateReceivedRanges(sessionId, receivedRange, sessionData.TotalBytes)
+				if err != nil {
+					c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error.", "error_details": err.Error()})
+					c.Abort()
+					return
+				}
+
+				progress.Publish(progress_models.EventChunkReceived, sessionId, map[string]any{
+					"range":          receivedRange,
+					"received_bytes": chunk_helpers.GetReceivedBytes(sessionData),
+					"total_bytes":    sessionData.TotalBytes,
+				})
+			}
+
+			if saveErr != nil {
+				c.Header("Upload-Offset", strconv.FormatInt(chunk_helpers.GetTusOffset(sessionData), 10))
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Append failed, query the offset and resume.", "error_details": saveErr.Error()})
+				c.Abort()
+				return
+			}
+		}
+		c.Header("Upload-Offset", strconv.FormatInt(chunk_helpers.GetTusOffset(sessionData), 10))
+
+		// If all the bytes have been received, enqueue the job moving the assembled file to the permanent location
+		// and storing it in the mini-io bucket.
+		if chunk_helpers.IsTusUploadComplete(sessionData) {
+			status, response := enqueueFinalizeJob(sessionId, job_models.JobSourceRanges)
+			if status != http.StatusAccepted {
+				c.JSON(status, response)
+				c.Abort()
+				return
+			}
+		}
+
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// Tus termination controller.
+func TusDelete() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		sessionId := c.Param("session_id")
+
+		// Check whether the session exists or not.
+		exists, err := chunk_helpers.ValidateSession(sessionId)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error.", "error_details": err.Error()})
+			c.Abort()
+			return
+		}
+		if !exists {
+			c.Status(http.StatusNotFound)
+			return
+		}
+
+		// Delete redis row item, temp folder and perm folder for that session id.
+		errorList := chunk_helpers.DeleteAllForSession(sessionId)
+		if len(errorList) > 0 {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error.", "error_list": errorList})
+			c.Abort()
+			return
+		}
+
+		c.Status(http.StatusNoContent)
+	}
+}
@@ -2,85 +2,103 @@ package helpers
 
 import (
 	chunk_models "ImageUploadMiniIo/pkg/image_chunks/models"
-	redis_database "ImageUploadMiniIo/pkg/redis"
-	redis_models "ImageUploadMiniIo/pkg/redis/models"
-	"encoding/json"
+	miniio "ImageUploadMiniIo/pkg/mini_io"
+	"ImageUploadMiniIo/pkg/session_folders"
+	"ImageUploadMiniIo/pkg/session_store"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"io"
+	"mime/multipart"
 	"net/http"
 	"os"
 	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	mapset "github.com/deckarep/golang-set/v2"
 	"github.com/gin-gonic/gin"
-	"github.com/go-redis/redis/v8"
+	"github.com/gin-gonic/gin/binding"
 	"github.com/google/uuid"
 )
 
-// Function to get the total chunks from the redis for a particular session id.
-func GetTotalChunks(sessionId string) (*int, error) {
-	// Get the redis client.
-	redisClient := redis_database.GetRedisClient()
+// Error returned when the session does not exist in the session store, either because it has expired or it never existed.
+var ErrSessionNotFound = session_store.ErrSessionNotFound
 
-	// Get the session data and check whether session has expired or not.
-	jsonData, err := redisClient.Client.Get(redisClient.Ctx, sessionId).Result()
-	if err == redis.Nil {
-		return nil, fmt.Errorf("session has expired")
-	} else if err != nil {
-		return nil, err
-	}
+// Time a session is kept in the session store, which the expiry time of the session and its cookie are derived from.
+const sessionTTL = 24 * time.Hour
 
-	var sessionData chunk_models.SessionData
-	err = json.Unmarshal([]byte(jsonData), &sessionData)
-	if err != nil {
-		return nil, err
+// Pattern the file type should match, as it is used as the extension of the files written for a session.
+var fileTypePattern = regexp.MustCompile(`^[A-Za-z0-9]{1,10}$`)
+
+// Error returned when the file type is not a plain file extension, which could otherwise write outside of the session folders.
+var ErrInvalidFileType = errors.New("file type should be made of 1 to 10 letters and digits")
+
+// Function to check that a file type is a plain file extension, without a dot or path separators.
+func ValidateFileType(fileType string) error {
+	if !fileTypePattern.MatchString(fileType) {
+		return ErrInvalidFileType
 	}
 
-	return &sessionData.TotalChunks, nil
+	return nil
 }
 
-// Function to update the chunk received list.
-func UpdateReceivedIdSet(c *gin.Context, sessionId string) (mapset.Set[int], error) {
-	// Get the redis client.
-	redisClient := redis_database.GetRedisClient()
-
-	// Check if the session exists or not.
-	jsonData, err := redisClient.Client.Get(redisClient.Ctx, sessionId).Result()
-	if err == redis.Nil {
-		return nil, fmt.Errorf("session has expired")
-	} else if err != nil {
-		return nil, err
-	}
+// Function to get the session data and its remaining time to live from the session store for a particular session id.
+func GetSessionData(sessionId string) (*chunk_models.SessionData, time.Duration, error) {
+	return session_store.GetSessionStore().Get(sessionId)
+}
 
-	var sessionData chunk_models.SessionData
-	err = json.Unmarshal([]byte(jsonData), &sessionData)
-	if err != nil {
-		return nil, err
+// Function to build the upload status of a particular session from its session data.
+func GetUploadStatus(sessionData *chunk_models.SessionData, ttl time.Duration) chunk_models.UploadStatusResponse {
+	var uploadStatus chunk_models.UploadStatusResponse
+	uploadStatus.SessionId = sessionData.SessionId
+	uploadStatus.FileDetails = sessionData.FileDetails
+	uploadStatus.FailedChunksInfo = sessionData.FailedChunksInfo
+	uploadStatus.ChunkFailCounts = sessionData.ChunkFailCounts
+	uploadStatus.MaxChunkRetries = GetMaxChunkRetries()
+	uploadStatus.TotalBytes = sessionData.TotalBytes
+	uploadStatus.ReceivedRanges = sessionData.ReceivedRanges
+	uploadStatus.TTLSeconds = int64(ttl.Seconds())
+	uploadStatus.ExpiryTime = sessionData.ExpiryTime
+
+	// Sort the received chunk numbers and compute the missing ones.
+	uploadStatus.ReceivedIds = sessionData.ReceivedIds.ToSlice()
+	sort.Ints(uploadStatus.ReceivedIds)
+
+	uploadStatus.MissingIds = make([]int, 0)
+	for i := 1; i <= sessionData.TotalChunks; i++ {
+		if !sessionData.ReceivedIds.Contains(i) {
+			uploadStatus.MissingIds = append(uploadStatus.MissingIds, i)
+		}
 	}
 
-	// Get the chunk details from the client request.
-	chunkDetails, err := GetChunkDetails(c)
-	if err != nil {
-		return nil, err
+	if uploadStatus.FailedChunksInfo == nil {
+		uploadStatus.FailedChunksInfo = make([]int, 0)
 	}
 
-	// Update the received chunk numbers.
-	sessionData.ReceivedIds.Add(chunkDetails.ChunkNumber)
+	return uploadStatus
+}
 
-	// Get the TTL.
-	ttl, err := redisClient.Client.TTL(redisClient.Ctx, sessionId).Result()
+// Function to get the total chunks from the session store for a particular session id.
+func GetTotalChunks(sessionId string) (*int, error) {
+	// Get the session data and check whether session has expired or not.
+	sessionData, _, err := GetSessionData(sessionId)
 	if err != nil {
 		return nil, err
 	}
 
-	// Set the updated list.
-	jsonData, err = redisClient.Client.Set(redisClient.Ctx, sessionId, sessionData, ttl).Result()
-	if err != nil {
-		return nil, err
-	}
+	return &sessionData.TotalChunks, nil
+}
 
-	// Unmarshling the received data.
-	err = json.Unmarshal([]byte(jsonData), &sessionData)
+// Function to update the chunk received list.
+func UpdateReceivedIdSet(sessionId string, chunkDetails *chunk_models.RequestData) (mapset.Set[int], error) {
+	// Update the received chunk numbers.
+	sessionData, err := session_store.GetSessionStore().MarkReceived(sessionId, chunkDetails.ChunkNumber)
 	if err != nil {
 		return nil, err
 	}
@@ -88,106 +106,47 @@ func UpdateReceivedIdSet(c *gin.Context, sessionId string) (mapset.Set[int], err
 	return sessionData.ReceivedIds, nil
 }
 
-// Function to get the file details from the client request and save in the models.FileDetails struct.
+// Function to get the file and chunk details from the client request and save in the models.RequestData struct.
+// The details are sent as multipart form fields along with the chunk, or as headers, which take precedence.
+// The request body is parsed only once, so that the chunk file can still be read from the form afterwards.
 func GetChunkDetails(c *gin.Context) (*chunk_models.RequestData, error) {
 	// Get the file details from the client request.
 	var requestData chunk_models.RequestData
-	err := c.BindJSON(&requestData)
+	err := c.ShouldBindWith(&requestData, binding.FormMultipart)
 	if err != nil {
 		return nil, err
 	}
-
-	return &requestData, nil
-}
-
-// Function to delete a session id from redis, if it exists.
-func DeleteSessionIfExists(redisClient *redis_models.RedisClient, compositeKey string) (string, error) {
-	// Search the value using the composite key if exists and delete it by returning the value.
-	value, err := redisClient.Client.Do(redisClient.Ctx, "GETDEL", compositeKey).Result()
-	if err != nil {
-		return "", err
-	}
-
-	// If there were no sessions corresponding to that ip address and user agent, return null string and null error.
-	if value == nil {
-		return "", nil
-	}
-
-	// If it exists, convert it to string and deserialise from JSON to a pre-defined struct and return the session id.
-
-	// Converting it into string.
-	deserialisedData, ok := value.(string)
-	if !ok {
-		return "", fmt.Errorf("unexpected value type: %T", deserialisedData)
-	}
-
-	// Deserialising from JSON to pre-defined struct.
-	var sessionData chunk_models.SessionData
-	err = json.Unmarshal([]byte(deserialisedData), &sessionData)
+	err = c.ShouldBindHeader(&requestData)
 	if err != nil {
-		return "", err
-	}
-
-	return sessionData.SessionId, nil
-}
-
-// Function to delete the temporary hidden folder containing the chunks for a particular session.
-func DeleteTempFolderPaths(sessionId string) error {
-	// Loading the environment variables.
-	tempFolderPath := os.Getenv("FOLDER_TEMP_PATH")
-
-	// Updating the folder paths by appending the session id.
-	tempFolderPath = tempFolderPath + "/" + sessionId
-
-	// Check if the folders exists.
-	if _, err := os.Stat(tempFolderPath); os.IsNotExist(err) {
-		return nil
-	} else if err != nil {
-		return err
+		return nil, err
 	}
 
-	// Remove the folders and its contents.
-	err := os.RemoveAll(tempFolderPath)
+	// The file type is part of the chunk file names, so it should be validated before any of them is written.
+	err = ValidateFileType(requestData.FileType)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	return nil
+	return &requestData, nil
 }
 
-// Function to delete the permanent folder containing the whole compiled file.
-func DeletePermFolderPaths(sessionId string) error {
-	// Loading the environment variables.
-	permFolderPath := os.Getenv("FOLDER_PERM_PATH")
-
-	// Updating the folder paths by appending the session id.
-	permFolderPath = permFolderPath + "/" + sessionId
-
-	// Check if the folders exists.
-	if _, err := os.Stat(permFolderPath); os.IsNotExist(err) {
-		return nil
-	} else if err != nil {
-		return err
-	}
-
-	// Remove the folders and its contents.
-	err := os.RemoveAll(permFolderPath)
-	if err != nil {
-		return err
+// Function to delete a session id from the session store, if it exists.
+// Returns the session id of the deleted session, empty if there was none.
+func DeleteSessionIfExists(compositeKey string) (string, error) {
+	sessionData, err := session_store.GetSessionStore().Delete(compositeKey)
+	if err != nil || sessionData == nil {
+		return "", err
 	}
 
-	return nil
+	return sessionData.SessionId, nil
 }
 
 // Function to create a cookie for the client session.
-func CreateCookie(c *gin.Context) (*http.Cookie, error) {
+func CreateCookie(c *gin.Context, requestData *chunk_models.RequestData) (*http.Cookie, error) {
 	// Search if any session already exists in the system with the same user agent and ip address.
 	// If yes delete the corresponding entry and corresponding temporary chunk folder and full file location
 	// from the system with the help of the session id present in the redis for that user.
 
-	// Getting the redis client.
-	redisClient := redis_database.GetRedisClient()
-
 	// Getting the ip address and user agent.
 	ipAddress := c.ClientIP()
 	userAgent := c.Request.UserAgent()
@@ -196,19 +155,19 @@ func CreateCookie(c *gin.Context) (*http.Cookie, error) {
 	compositeKey := ipAddress + ":" + userAgent
 
 	// Deleting the session if exists.
-	deletedSessionId, err := DeleteSessionIfExists(redisClient, compositeKey)
+	deletedSessionId, err := DeleteSessionIfExists(compositeKey)
 	if err != nil {
 		return nil, err
 	}
 
 	// If session already existed, deleting the existing folders, if exists.
 	if deletedSessionId != "" {
-		err = DeleteTempFolderPaths(deletedSessionId)
+		err = session_folders.DeleteTempFolder(deletedSessionId)
 		if err != nil {
 			return nil, err
 		}
 
-		err = DeletePermFolderPaths(deletedSessionId)
+		err = session_folders.DeletePermFolder(deletedSessionId)
 		if err != nil {
 			return nil, err
 		}
@@ -225,65 +184,163 @@ func CreateCookie(c *gin.Context) (*http.Cookie, error) {
 	cookie := &http.Cookie{
 		Name:     "sessionId",
 		Value:    sessionId,
-		Expires:  currentTime.Add(time.Hour),
+		Expires:  currentTime.Add(sessionTTL),
 		HttpOnly: true,
 	}
 
-	// Create the session data to be stored in redis.
-
-	// Getting the data passed in the client request.
-	requestData, err := GetChunkDetails(c)
-	if err != nil {
-		return nil, err
-	}
-
+	// Create the session data to be stored in redis, from the data passed in the client request.
 	var fileDetails chunk_models.FileDetails
 	fileDetails.FileName = requestData.FileName
 	fileDetails.FileSize = requestData.FileSize
 	fileDetails.FileSizeUnit = requestData.FileSizeUnit
 	fileDetails.FileType = requestData.FileType
 	fileDetails.TotalChunks = requestData.TotalChunks
+	fileDetails.FileChecksum = strings.ToLower(strings.TrimSpace(requestData.FileChecksum))
 
-	// Setting the data for session data.
+	// Setting the data for session data, starting the multipart upload if needed and writing it to the redis.
+	sessionData := newSessionData(sessionId, ipAddress, userAgent, fileDetails, currentTime)
+	err = initMultipartUpload(sessionData)
+	if err != nil {
+		return nil, err
+	}
+	err = saveSessionData(sessionData)
+	if err != nil {
+		return nil, err
+	}
+
+	return cookie, nil
+}
+
+// Function to build the session data for a new session.
+func newSessionData(sessionId string, ipAddress string, userAgent string, fileDetails chunk_models.FileDetails, currentTime time.Time) *chunk_models.SessionData {
 	var sessionData chunk_models.SessionData
 	sessionData.SessionId = sessionId
 	sessionData.IPAddress = ipAddress
 	sessionData.UserAgent = userAgent
 	sessionData.FileDetails = fileDetails
 	sessionData.CreationTime = currentTime
-	sessionData.ExpiryTime = currentTime.Add(time.Hour)
+	sessionData.ExpiryTime = currentTime.Add(sessionTTL)
 	sessionData.FailedChunksInfo = make([]int, 0)
+	sessionData.ChunkFailCounts = make(map[int]int)
 	sessionData.ReceivedIds = mapset.NewSet[int]()
+	sessionData.Staged = IsStagingMode()
 
-	// Write this data to the redis.
-	jsonData, err := json.Marshal(sessionData)
+	return &sessionData
+}
+
+// Function to write the session data to the session store against its session id.
+func saveSessionData(sessionData *chunk_models.SessionData) error {
+	return session_store.GetSessionStore().Create(sessionData, sessionTTL)
+}
+
+// Function to check whether the chunks should be streamed straight into a mini-io multipart upload,
+// instead of being saved in the temporary folder and compiled in the permanent folder.
+func IsMultipartMode() bool {
+	// Loading the environment variables.
+	return os.Getenv("CHUNK_UPLOAD_MODE") == "multipart"
+}
+
+// Function to start the multipart upload of a chunk based session, when the multipart mode is enabled.
+func initMultipartUpload(sessionData *chunk_models.SessionData) error {
+	if !IsMultipartMode() || sessionData.TotalChunks == 0 {
+		return nil
+	}
+
+	// Every part except the last one should be at least of the minimum part size.
+	if sessionData.TotalChunks > 1 {
+		fileSize, err := sessionData.FileDetails.SizeInBytes()
+		if err != nil {
+			return err
+		}
+		if fileSize/int64(sessionData.TotalChunks) < miniio.MinPartSize {
+			return fmt.Errorf("chunks should be at least %d bytes each, except the last one", miniio.MinPartSize)
+		}
+	}
+
+	uploadId, err := miniio.NewSessionMultipartUpload(sessionData)
+	if err != nil {
+		return err
+	}
+	sessionData.MultipartUploadId = uploadId
+	sessionData.MultipartParts = make(map[int]string)
+
+	return nil
+}
+
+// Function to create a new upload session from the file details passed in the client request.
+// Unlike CreateCookie, it does not tear down other sessions of the same client, so that a client
+// can run several uploads in parallel.
+func CreateSession(c *gin.Context) (*chunk_models.SessionData, error) {
+	// Get the file details from the client request.
+	var fileDetails chunk_models.FileDetails
+	err := c.BindJSON(&fileDetails)
 	if err != nil {
 		return nil, err
 	}
 
-	err = redisClient.Client.Set(redisClient.Ctx, sessionId, jsonData, 24*time.Hour).Err()
+	// Validate the file details.
+	if fileDetails.FileName == "" {
+		return nil, fmt.Errorf("file name is required")
+	}
+	if err := ValidateFileType(fileDetails.FileType); err != nil {
+		return nil, err
+	}
+	if fileDetails.TotalChunks < 0 {
+		return nil, fmt.Errorf("total chunks should not be negative")
+	}
+	if fileDetails.TotalChunks == 0 && fileDetails.FileSize <= 0 {
+		return nil, fmt.Errorf("either total chunks or file size should be greater than zero")
+	}
+	if fileDetails.FileSize < 0 {
+		return nil, fmt.Errorf("file size should not be negative")
+	}
+	if _, err := fileDetails.SizeUnitInBytes(); err != nil {
+		return nil, err
+	}
+	fileDetails.FileChecksum = strings.ToLower(strings.TrimSpace(fileDetails.FileChecksum))
+
+	// Create the session data, start the multipart upload if needed and write it to the session store.
+	sessionData := newSessionData(uuid.NewString(), c.ClientIP(), c.Request.UserAgent(), fileDetails, time.Now())
+	err = initMultipartUpload(sessionData)
+	if err != nil {
+		return nil, err
+	}
+	err = saveSessionData(sessionData)
 	if err != nil {
 		return nil, err
 	}
 
-	return cookie, nil
+	return sessionData, nil
 }
 
-// Function to validate the session, whether it exists and is not expired stored in redis.
-func ValidateSession(sessionId string) (bool, error) {
-	// Getting the redis client.
-	redisClient := redis_database.GetRedisClient()
+// Function to build the chunk plan the client should follow for a particular session.
+// The chunk size is in bytes, whatever the unit the file size has been declared in.
+func GetChunkPlan(fileDetails chunk_models.FileDetails) (*chunk_models.ChunkPlan, error) {
+	var chunkPlan chunk_models.ChunkPlan
+	chunkPlan.TotalChunks = fileDetails.TotalChunks
+	chunkPlan.ChunkNumbers = make([]int, 0, fileDetails.TotalChunks)
 
-	// Search in the redis with the sessionId as the key if it exists or not.
-	// If exists returns true, otherwise, return false.
-	result, err := redisClient.Client.Exists(redisClient.Ctx, sessionId).Result()
-	if err != nil {
-		return false, err
+	// Every chunk except the last one carries the same size, the last one carries the remainder.
+	if fileDetails.TotalChunks > 0 {
+		fileSize, err := fileDetails.SizeInBytes()
+		if err != nil {
+			return nil, err
+		}
+		chunkPlan.ChunkSize = (fileSize + int64(fileDetails.TotalChunks) - 1) / int64(fileDetails.TotalChunks)
+	}
+
+	for i := 1; i <= fileDetails.TotalChunks; i++ {
+		chunkPlan.ChunkNumbers = append(chunkPlan.ChunkNumbers, i)
 	}
 
-	// In the above if case, if it is already an error, then session validation failed, otherwise, it either exists or not.
-	// Result = 0 --> does not exist & Result = 1 --> exists.
-	return result == 1, nil
+	return &chunkPlan, nil
+}
+
+// Function to validate the session, whether it exists and is not expired stored in the session store.
+func ValidateSession(sessionId string) (bool, error) {
+	// Search in the session store with the sessionId as the key if it exists or not.
+	// If exists returns true, otherwise, return false.
+	return session_store.GetSessionStore().Exists(sessionId)
 }
 
 // Function to create a temporary hidden folder for a particular session to save the uploaded chunks.
@@ -318,81 +375,195 @@ func saveChunkTempLocation(c *gin.Context, sessionId string, folderPath string,
 		return err
 	}
 
-	// Saving the file.
-	if err := c.SaveUploadedFile(file, filePath); err != nil {
+	src, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	// Saving the file, while hashing the bytes being written.
+	dst, err := os.Create(filePath)
+	if err != nil {
+		return err
+	}
+	defer dst.Close()
+
+	hasher := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(dst, hasher), src); err != nil {
 		return err
 	}
 
+	// If the client has sent a checksum for the chunk, verify it against the written bytes.
+	// On mismatch, remove the corrupted chunk so that it is not compiled into the final file.
+	expectedChecksum := getChunkChecksum(chunkDetails)
+	if expectedChecksum != "" && expectedChecksum != hex.EncodeToString(hasher.Sum(nil)) {
+		dst.Close()
+		os.Remove(filePath)
+		return ErrChunkChecksumMismatch
+	}
+
 	return nil
 }
 
+// Function to stream a chunk as a part of the multipart upload of a particular session.
+// The chunk is verified before it is uploaded, so that a corrupted chunk never reaches the bucket.
+func saveChunkMultipartPart(c *gin.Context, sessionData *chunk_models.SessionData, chunkDetails *chunk_models.RequestData) error {
+	// Get the file from the request.
+	file, err := c.FormFile("file")
+	if err != nil {
+		return err
+	}
+
+	// If the client has sent a checksum for the chunk, verify it against the received bytes first.
+	// On mismatch, the part recorded for the chunk before, if any, is dropped so that it is re-sent.
+	expectedChecksum := getChunkChecksum(chunkDetails)
+	if expectedChecksum != "" {
+		actualChecksum, err := getFormFileChecksum(file)
+		if err != nil {
+			return err
+		}
+		if expectedChecksum != actualChecksum {
+			err = updateSessionData(sessionData.SessionId, func(sessionData *chunk_models.SessionData) {
+				delete(sessionData.MultipartParts, chunkDetails.ChunkNumber)
+			})
+			if err != nil {
+				return err
+			}
+			return ErrChunkChecksumMismatch
+		}
+	}
+
+	// Upload the part, the form file is read again from the start.
+	src, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	eTag, err := miniio.UploadSessionChunkPart(sessionData, chunkDetails.ChunkNumber, src, file.Size)
+	if err != nil {
+		return err
+	}
+
+	// Record the ETag of the part, needed to complete the multipart upload.
+	return updateSessionData(sessionData.SessionId, func(sessionData *chunk_models.SessionData) {
+		if sessionData.MultipartParts == nil {
+			sessionData.MultipartParts = make(map[int]string)
+		}
+		sessionData.MultipartParts[chunkDetails.ChunkNumber] = eTag
+	})
+}
+
+// Function to get the SHA-256 checksum of a file sent in the client request.
+// The form file is kept in memory or in a temporary file by the form parsing, so that it can be read more than once.
+func getFormFileChecksum(file *multipart.FileHeader) (string, error) {
+	src, err := file.Open()
+	if err != nil {
+		return "", err
+	}
+	defer src.Close()
+
+	hasher := sha256.New()
+	if _, err := io.Copy(hasher, src); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(hasher.Sum(nil)), nil
+}
+
+// Error returned when the SHA-256 checksum sent by the client does not match the received chunk.
+var ErrChunkChecksumMismatch = errors.New("chunk checksum mismatch")
+
+// Function to get the SHA-256 checksum of the chunk sent by the client, either as a header or as a form field.
+func getChunkChecksum(chunkDetails *chunk_models.RequestData) string {
+	return strings.ToLower(strings.TrimSpace(chunkDetails.ChunkChecksum))
+}
+
 // Function to help in different processes of uploading the chunks for a particular session.
-func UploadChunkHelper(c *gin.Context, sessionId string) (*int, error) {
-	// Check whether the file location already exists or not. If not then make one.
-	folderPath, err := createTempFolder(sessionId)
+// Once the chunk number is known it is returned along with any error, so that the failed chunk can be recorded.
+func UploadChunkHelper(c *gin.Context, sessionId string, chunkDetails *chunk_models.RequestData) (*int, error) {
+	// Stream the chunk into the multipart upload of the session, if it has one.
+	sessionData, _, err := GetSessionData(sessionId)
 	if err != nil {
 		return nil, err
 	}
+	if sessionData.MultipartUploadId != "" {
+		err = saveChunkMultipartPart(c, sessionData, chunkDetails)
+		if err != nil {
+			return &chunkDetails.ChunkNumber, err
+		}
+
+		return &chunkDetails.ChunkNumber, nil
+	}
+
+	// Stage the chunk in the object store, if the session is staged, so that it can be assembled on any node.
+	if sessionData.Staged {
+		err = saveChunkStaged(c, sessionData, chunkDetails)
+		if err != nil {
+			return &chunkDetails.ChunkNumber, err
+		}
+
+		return &chunkDetails.ChunkNumber, nil
+	}
 
-	// Get the chunk details from the client request.
-	chunkDetails, err := GetChunkDetails(c)
+	// Check whether the file location already exists or not. If not then make one.
+	folderPath, err := createTempFolder(sessionId)
 	if err != nil {
-		return nil, err
+		return &chunkDetails.ChunkNumber, err
 	}
 
 	// Temporarily save the chunk in the location.
 	err = saveChunkTempLocation(c, sessionId, folderPath, chunkDetails)
 	if err != nil {
-		return nil, err
+		return &chunkDetails.ChunkNumber, err
 	}
 
 	return &chunkDetails.ChunkNumber, nil
 }
 
-// Function to update the redis failed list for a particular session, if any chunk upload activity fails.
-func UpdateRedisFailedList(c *gin.Context, sessionId string, failedChunkNumber int) error {
-	// Get the redis client.
-	redisClient := redis_database.GetRedisClient()
-
-	// Check if the session Id exists or it has expired.
-	jsonData, err := redisClient.Client.Get(redisClient.Ctx, sessionId).Result()
-	if err == redis.Nil {
-		return fmt.Errorf("session expired")
-	} else if err != nil {
-		return err
+// Function to get the maximum number of times a chunk can fail before the whole session is abandoned.
+func GetMaxChunkRetries() int {
+	// Loading the environment variables.
+	maxRetries, err := strconv.Atoi(os.Getenv("CHUNK_MAX_RETRIES"))
+	if err != nil || maxRetries < 0 {
+		return 3
 	}
 
-	// The key exists and now you need to update the failed chunk number list.
-	// Deserialise the json data into the models.Session structure.
-	var sessionData chunk_models.SessionData
-	err = json.Unmarshal([]byte(jsonData), &sessionData)
+	return maxRetries
+}
+
+// Function to read, update and write back the session data for a particular session, keeping its TTL.
+func updateSessionData(sessionId string, update func(sessionData *chunk_models.SessionData)) error {
+	_, err := session_store.GetSessionStore().Update(sessionId, update)
+
+	return err
+}
+
+// Function to update the failed list in the session store for a particular session, if any chunk upload activity fails.
+// Returns the number of times the chunk has failed so far.
+func UpdateFailedList(sessionId string, failedChunkNumber int) (int, error) {
+	// Updating the failed chunk number list for the session id and the number of times the chunk has failed.
+	return session_store.GetSessionStore().MarkFailed(sessionId, failedChunkNumber)
+}
+
+// Function to remove a chunk from the failed list in the session store for a particular session, once it has been re-sent successfully.
+func ClearFailedChunk(sessionId string, chunkNumber int) error {
+	// Check first whether the chunk has failed before, so that the session data is only written when needed.
+	failedList, err := CheckFailStatus(sessionId)
 	if err != nil {
 		return err
 	}
+	if !slices.Contains(failedList, chunkNumber) {
+		return nil
+	}
 
-	// Updating the failed chunk number list for the session id.
-	sessionData.FailedChunksInfo = append(sessionData.FailedChunksInfo, failedChunkNumber)
-
-	return nil
+	return session_store.GetSessionStore().ClearFailed(sessionId, chunkNumber)
 }
 
 // Function to check whether for a particular session any chunk upload failed or not.
 func CheckFailStatus(sessionId string) ([]int, error) {
-	// Get the redis client.
-	redisClient := redis_database.GetRedisClient()
-
 	// Get the session data.
-	jsonData, err := redisClient.Client.Get(redisClient.Ctx, sessionId).Result()
-	if err == redis.Nil {
-		return nil, fmt.Errorf("session expired")
-	} else if err != nil {
-		return nil, err
-	}
-
-	// The key exists and now you need to update the failed chunk number list.
-	// Deserialise the json data into the models.Session structure.
-	var sessionData chunk_models.SessionData
-	err = json.Unmarshal([]byte(jsonData), &sessionData)
+	sessionData, _, err := GetSessionData(sessionId)
 	if err != nil {
 		return nil, err
 	}
@@ -406,26 +577,84 @@ func CheckFailStatus(sessionId string) ([]int, error) {
 	return nil, nil
 }
 
+// Function to describe the resources held by a particular session, before they are cleaned up.
+func GetSessionResources(sessionId string) (*chunk_models.SessionResources, error) {
+	var sessionResources chunk_models.SessionResources
+	sessionResources.SessionId = sessionId
+
+	// Check whether the session data exists in the session store.
+	exists, err := ValidateSession(sessionId)
+	if err != nil {
+		return nil, err
+	}
+	sessionResources.RedisEntry = exists
+
+	// Check whether the temporary folder exists and count the chunks saved inside it.
+	tempFolderPath := session_folders.GetTempFolderPath(sessionId)
+	chunkFiles, err := os.ReadDir(tempFolderPath)
+	if err == nil {
+		sessionResources.TempFolder = true
+		sessionResources.ChunkFiles = len(chunkFiles)
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	// Check whether the permanent folder exists.
+	permFolderPath := session_folders.GetPermFolderPath(sessionId)
+	if _, err := os.Stat(permFolderPath); err == nil {
+		sessionResources.PermFolder = true
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	// Count the objects staged for the session in the object store.
+	if IsStagingMode() {
+		sessionResources.StagedObjects, err = miniio.CountStagedObjects(sessionId)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &sessionResources, nil
+}
+
 // Function to delete all information for a particular session id.
 func DeleteAllForSession(sessionId string) []error {
 	var errors []error
 
 	// Deleting the permanent folder and its contents created for a particular session.
-	err := DeletePermFolderPaths(sessionId)
+	err := session_folders.DeletePermFolder(sessionId)
 	if err != nil {
 		errors = append(errors, err)
 	}
 
 	// Deleting the temporary folder and its contents created for a particular session.
-	err = DeleteTempFolderPaths(sessionId)
+	err = session_folders.DeleteTempFolder(sessionId)
 	if err != nil {
 		errors = append(errors, err)
 	}
 
-	// Deleteing the session id details from the redis storage for a particular session.
-	// Get the redis client.
-	redisClient := redis_database.GetRedisClient()
-	_, err = DeleteSessionIfExists(redisClient, sessionId)
+	// Aborting the multipart upload of the session, if it has one and it has not been completed.
+	sessionData, _, err := GetSessionData(sessionId)
+	if err == nil && sessionData.MultipartUploadId != "" {
+		err = miniio.AbortSessionMultipartUpload(sessionId, sessionData.MultipartUploadId)
+		if err != nil {
+			errors = append(errors, err)
+		}
+	} else if err != nil && err != ErrSessionNotFound {
+		errors = append(errors, err)
+	}
+
+	// Removing the objects staged for the session, if it is staged or its multipart upload has been completed.
+	if sessionData != nil && (sessionData.Staged || sessionData.MultipartUploadId != "") {
+		_, err = miniio.RemoveStagedObjects(sessionId)
+		if err != nil {
+			errors = append(errors, err)
+		}
+	}
+
+	// Deleteing the session id details from the session store for a particular session.
+	_, err = DeleteSessionIfExists(sessionId)
 	if err != nil {
 		errors = append(errors, err)
 	}
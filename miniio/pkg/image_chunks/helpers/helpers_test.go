@@ -0,0 +1,84 @@
+package helpers
+
+import (
+	chunk_models "ImageUploadMiniIo/pkg/image_chunks/models"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestGetChunkPlan(t *testing.T) {
+	tests := []struct {
+		name          string
+		fileDetails   chunk_models.FileDetails
+		wantChunkSize int64
+		wantChunks    []int
+		wantErr       bool
+	}{
+		{name: "bytes divided evenly", fileDetails: chunk_models.FileDetails{FileSize: 100, FileSizeUnit: "B", TotalChunks: 4}, wantChunkSize: 25, wantChunks: []int{1, 2, 3, 4}},
+		{name: "bytes rounded up", fileDetails: chunk_models.FileDetails{FileSize: 10, TotalChunks: 3}, wantChunkSize: 4, wantChunks: []int{1, 2, 3}},
+		{name: "kilobytes in bytes", fileDetails: chunk_models.FileDetails{FileSize: 3, FileSizeUnit: "KB", TotalChunks: 2}, wantChunkSize: 1536, wantChunks: []int{1, 2}},
+		{name: "megabytes rounded up", fileDetails: chunk_models.FileDetails{FileSize: 10, FileSizeUnit: "mb", TotalChunks: 3}, wantChunkSize: 3495254, wantChunks: []int{1, 2, 3}},
+		{name: "gigabytes beyond int32", fileDetails: chunk_models.FileDetails{FileSize: 8, FileSizeUnit: "GB", TotalChunks: 2}, wantChunkSize: 4 << 30, wantChunks: []int{1, 2}},
+		{name: "single chunk", fileDetails: chunk_models.FileDetails{FileSize: 7, FileSizeUnit: "B", TotalChunks: 1}, wantChunkSize: 7, wantChunks: []int{1}},
+		{name: "no chunks", fileDetails: chunk_models.FileDetails{FileSize: 7, FileSizeUnit: "B"}, wantChunkSize: 0, wantChunks: []int{}},
+		{name: "unknown unit", fileDetails: chunk_models.FileDetails{FileSize: 7, FileSizeUnit: "TB", TotalChunks: 1}, wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			chunkPlan, err := GetChunkPlan(test.fileDetails)
+			if test.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got %+v", chunkPlan)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if chunkPlan.ChunkSize != test.wantChunkSize {
+				t.Errorf("chunk size = %d, want %d", chunkPlan.ChunkSize, test.wantChunkSize)
+			}
+			if chunkPlan.TotalChunks != test.fileDetails.TotalChunks {
+				t.Errorf("total chunks = %d, want %d", chunkPlan.TotalChunks, test.fileDetails.TotalChunks)
+			}
+			if !slices.Equal(chunkPlan.ChunkNumbers, test.wantChunks) {
+				t.Errorf("chunk numbers = %v, want %v", chunkPlan.ChunkNumbers, test.wantChunks)
+			}
+		})
+	}
+}
+
+func TestValidateFileType(t *testing.T) {
+	tests := []struct {
+		name     string
+		fileType string
+		wantErr  bool
+	}{
+		{name: "extension", fileType: "png"},
+		{name: "upper case with digits", fileType: "MP4"},
+		{name: "empty", fileType: "", wantErr: true},
+		{name: "with a dot", fileType: ".png", wantErr: true},
+		{name: "parent folder", fileType: "../../x", wantErr: true},
+		{name: "path separator", fileType: "png/x", wantErr: true},
+		{name: "too long", fileType: "abcdefghijk", wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := ValidateFileType(test.fileType); (err != nil) != test.wantErr {
+				t.Errorf("error = %v, want error %v", err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestNewSessionDataExpiresWithTheStore(t *testing.T) {
+	creationTime := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	sessionData := newSessionData("session", "127.0.0.1", "agent", chunk_models.FileDetails{TotalChunks: 2}, creationTime)
+
+	if got := sessionData.ExpiryTime.Sub(sessionData.CreationTime); got != sessionTTL {
+		t.Errorf("session expires %s after its creation, want the store TTL of %s", got, sessionTTL)
+	}
+}
@@ -0,0 +1,227 @@
+package helpers
+
+import (
+	"ImageUploadMiniIo/pkg/chunk_manager"
+	chunk_models "ImageUploadMiniIo/pkg/image_chunks/models"
+	"bytes"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Error returned when the Content-Range header is missing or malformed.
+var ErrInvalidContentRange = errors.New("invalid content range, expected \"bytes start-end/total\"")
+
+// Error returned when the checksum of the request body does not match the one sent by the client.
+var ErrBodyChecksumMismatch = errors.New("request body checksum mismatch")
+
+// Checksum the client has sent for the request body, along with the hash computed while the body is read.
+type BodyChecksum struct {
+	Hasher   hash.Hash
+	Expected []byte
+}
+
+// Function to check whether the bytes hashed so far match the checksum sent by the client.
+func (bodyChecksum *BodyChecksum) matches() bool {
+	return bytes.Equal(bodyChecksum.Hasher.Sum(nil), bodyChecksum.Expected)
+}
+
+// Maximum total size in bytes of a range upload used when none is configured.
+const defaultRangeMaxSize = 1 << 30
+
+// Function to get the maximum total size in bytes of a range upload, as the ranges are written at their offsets
+// inside the range file and a large total would otherwise grow it without bound.
+func GetRangeMaxSize() int64 {
+	// Loading the environment variables.
+	maxSize, err := strconv.ParseInt(os.Getenv("RANGE_MAX_SIZE"), 10, 64)
+	if err != nil || maxSize <= 0 {
+		return defaultRangeMaxSize
+	}
+
+	return maxSize
+}
+
+// Regular expression matching the Content-Range header of the form "bytes start-end/total".
+var contentRangeRegex = regexp.MustCompile(`^bytes (\d+)-(\d+)/(\d+)$`)
+
+// Function to parse the Content-Range header of the client request into the byte range and the total size.
+func ParseContentRange(contentRange string) (*chunk_models.ByteRange, int64, error) {
+	matches := contentRangeRegex.FindStringSubmatch(contentRange)
+	if matches == nil {
+		return nil, 0, ErrInvalidContentRange
+	}
+
+	var byteRange chunk_models.ByteRange
+	var err error
+	byteRange.Start, err = strconv.ParseInt(matches[1], 10, 64)
+	if err != nil {
+		return nil, 0, ErrInvalidContentRange
+	}
+	byteRange.End, err = strconv.ParseInt(matches[2], 10, 64)
+	if err != nil {
+		return nil, 0, ErrInvalidContentRange
+	}
+	total, err := strconv.ParseInt(matches[3], 10, 64)
+	if err != nil {
+		return nil, 0, ErrInvalidContentRange
+	}
+
+	// The end of the range is inclusive and should lie within the total size.
+	if byteRange.Start > byteRange.End || byteRange.End >= total {
+		return nil, 0, ErrInvalidContentRange
+	}
+
+	return &byteRange, total, nil
+}
+
+// Function to validate the byte range against the session, before anything is written.
+func ValidateRange(sessionData *chunk_models.SessionData, total int64) error {
+	// The total size should be within the configured maximum, whether or not the file size has been declared.
+	if maxSize := GetRangeMaxSize(); total > maxSize {
+		return fmt.Errorf("total size %d exceeds the maximum size of %d bytes", total, maxSize)
+	}
+
+	// Every range of a session should carry the same total size.
+	if sessionData.TotalBytes != 0 && sessionData.TotalBytes != total {
+		return fmt.Errorf("total size %d does not match the total size %d of earlier ranges", total, sessionData.TotalBytes)
+	}
+
+	// If the client has declared the file size at session creation, the total size should agree with it.
+	if sessionData.FileSize > 0 {
+		matches, err := sessionData.FileDetails.MatchesSize(total)
+		if err != nil {
+			return err
+		}
+		if !matches {
+			return fmt.Errorf("total size %d does not match the declared file size %d %s", total, sessionData.FileSize, sessionData.FileSizeUnit)
+		}
+	}
+
+	return nil
+}
+
+// Function to write the request body at the position of the byte range inside the range file of the session.
+// If the session is staged, the range is staged in the object store instead, so that it can be assembled on any node.
+// Returns the number of bytes written from the start of the range, which are kept even if the body ends early.
+// If the client has sent a checksum for the body, nothing is kept unless the whole range matches it.
+func SaveRange(c *gin.Context, sessionData *chunk_models.SessionData, byteRange *chunk_models.ByteRange, bodyChecksum *BodyChecksum) (int64, error) {
+	body := io.Reader(c.Request.Body)
+	if bodyChecksum != nil {
+		body = io.TeeReader(body, bodyChecksum.Hasher)
+	}
+
+	var written int64
+	var err error
+	if sessionData.Staged {
+		written, err = saveRangeStaged(sessionData, byteRange, body, bodyChecksum)
+	} else {
+		written, err = saveRangeLocal(sessionData, byteRange, body)
+	}
+	if bodyChecksum != nil && (err != nil || !bodyChecksum.matches()) {
+		if err == nil {
+			err = ErrBodyChecksumMismatch
+		}
+		return 0, err
+	}
+
+	return written, err
+}
+
+// Function to write the body at the position of the byte range inside the range file of the session.
+// The bytes written are left in the file, they are only taken into account once the range is recorded.
+func saveRangeLocal(sessionData *chunk_models.SessionData, byteRange *chunk_models.ByteRange, body io.Reader) (int64, error) {
+	// Check whether the temporary folder already exists or not. If not then make one.
+	_, err := createTempFolder(sessionData.SessionId)
+	if err != nil {
+		return 0, err
+	}
+
+	// Open the range file without truncating, as the other ranges may already be written into it.
+	rangeFile, err := os.OpenFile(chunk_manager.GetRangeFilePath(sessionData.SessionId, sessionData.FileType), os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return 0, err
+	}
+	defer rangeFile.Close()
+
+	// Write at most the number of bytes of the range at its offset.
+	return copyRange(io.NewOffsetWriter(rangeFile, byteRange.Start), byteRange, body)
+}
+
+// Function to copy the bytes of a byte range from the body into the writer.
+// Returns the number of bytes copied, along with an error if fewer bytes than the length of the range have been received.
+func copyRange(writer io.Writer, byteRange *chunk_models.ByteRange, body io.Reader) (int64, error) {
+	rangeLength := byteRange.End - byteRange.Start + 1
+	written, err := io.Copy(writer, io.LimitReader(body, rangeLength))
+	if err != nil {
+		return written, err
+	}
+	if written != rangeLength {
+		return written, fmt.Errorf("received %d bytes for a range of %d bytes", written, rangeLength)
+	}
+
+	return written, nil
+}
+
+// Function to add a byte range to the received ranges, merging the overlapping and adjacent ones.
+func mergeRanges(receivedRanges []chunk_models.ByteRange, byteRange chunk_models.ByteRange) []chunk_models.ByteRange {
+	ranges := append(append([]chunk_models.ByteRange{}, receivedRanges...), byteRange)
+	sort.Slice(ranges, func(i, j int) bool {
+		return ranges[i].Start < ranges[j].Start
+	})
+
+	merged := make([]chunk_models.ByteRange, 0, len(ranges))
+	for _, current := range ranges {
+		last := len(merged) - 1
+		if last >= 0 && current.Start <= merged[last].End+1 {
+			if current.End > merged[last].End {
+				merged[last].End = current.End
+			}
+			continue
+		}
+		merged = append(merged, current)
+	}
+
+	return merged
+}
+
+// Function to update the received byte ranges in the redis for a particular session.
+// Returns the updated session data.
+func UpdateReceivedRanges(sessionId string, byteRange chunk_models.ByteRange, total int64) (*chunk_models.SessionData, error) {
+	var updatedSessionData *chunk_models.SessionData
+	err := updateSessionData(sessionId, func(sessionData *chunk_models.SessionData) {
+		sessionData.TotalBytes = total
+		sessionData.ReceivedRanges = mergeRanges(sessionData.ReceivedRanges, byteRange)
+		updatedSessionData = sessionData
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return updatedSessionData, nil
+}
+
+// Function to check whether all the bytes of a range based upload have been received.
+// The total size is only known once the first range has been received, so an upload is never complete without one.
+func IsRangeUploadComplete(sessionData *chunk_models.SessionData) bool {
+	return sessionData.TotalBytes > 0 &&
+		len(sessionData.ReceivedRanges) == 1 &&
+		sessionData.ReceivedRanges[0].Start == 0 &&
+		sessionData.ReceivedRanges[0].End == sessionData.TotalBytes-1
+}
+
+// Function to get the number of bytes received so far for a range based upload.
+func GetReceivedBytes(sessionData *chunk_models.SessionData) int64 {
+	var receivedBytes int64
+	for _, byteRange := range sessionData.ReceivedRanges {
+		receivedBytes += byteRange.End - byteRange.Start + 1
+	}
+
+	return receivedBytes
+}
@@ -0,0 +1,102 @@
+package helpers
+
+import (
+	chunk_models "ImageUploadMiniIo/pkg/image_chunks/models"
+	"slices"
+	"testing"
+)
+
+func TestParseContentRange(t *testing.T) {
+	tests := []struct {
+		name         string
+		contentRange string
+		wantRange    chunk_models.ByteRange
+		wantTotal    int64
+		wantErr      bool
+	}{
+		{name: "first byte", contentRange: "bytes 0-0/10", wantRange: chunk_models.ByteRange{Start: 0, End: 0}, wantTotal: 10},
+		{name: "whole file", contentRange: "bytes 0-9/10", wantRange: chunk_models.ByteRange{Start: 0, End: 9}, wantTotal: 10},
+		{name: "end past the total", contentRange: "bytes 0-10/10", wantErr: true},
+		{name: "start after the end", contentRange: "bytes 5-4/10", wantErr: true},
+		{name: "unknown total", contentRange: "bytes 0-9/*", wantErr: true},
+		{name: "other unit", contentRange: "items 0-9/10", wantErr: true},
+		{name: "empty", contentRange: "", wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			byteRange, total, err := ParseContentRange(test.contentRange)
+			if test.wantErr {
+				if err != ErrInvalidContentRange {
+					t.Fatalf("error = %v, want %v", err, ErrInvalidContentRange)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if *byteRange != test.wantRange || total != test.wantTotal {
+				t.Errorf("range = %+v of %d, want %+v of %d", *byteRange, total, test.wantRange, test.wantTotal)
+			}
+		})
+	}
+}
+
+func TestValidateRange(t *testing.T) {
+	tests := []struct {
+		name         string
+		rangeMaxSize string
+		fileSize     int
+		totalBytes   int64
+		total        int64
+		wantErr      bool
+	}{
+		{name: "first range", total: 10},
+		{name: "same total as earlier ranges", totalBytes: 10, total: 10},
+		{name: "other total than earlier ranges", totalBytes: 10, total: 11, wantErr: true},
+		{name: "declared file size", fileSize: 10, total: 10},
+		{name: "other size than declared", fileSize: 10, total: 11, wantErr: true},
+		{name: "beyond the default maximum", total: 1099511627778, wantErr: true},
+		{name: "beyond the configured maximum", rangeMaxSize: "100", total: 101, wantErr: true},
+		{name: "within the configured maximum", rangeMaxSize: "100", total: 100},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Setenv("RANGE_MAX_SIZE", test.rangeMaxSize)
+
+			var sessionData chunk_models.SessionData
+			sessionData.FileSize = test.fileSize
+			sessionData.FileSizeUnit = "B"
+			sessionData.TotalBytes = test.totalBytes
+
+			if err := ValidateRange(&sessionData, test.total); (err != nil) != test.wantErr {
+				t.Errorf("error = %v, want error %v", err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestMergeRanges(t *testing.T) {
+	tests := []struct {
+		name           string
+		receivedRanges []chunk_models.ByteRange
+		byteRange      chunk_models.ByteRange
+		want           []chunk_models.ByteRange
+	}{
+		{name: "first range", byteRange: chunk_models.ByteRange{Start: 0, End: 9}, want: []chunk_models.ByteRange{{Start: 0, End: 9}}},
+		{name: "disjoint", receivedRanges: []chunk_models.ByteRange{{Start: 20, End: 29}}, byteRange: chunk_models.ByteRange{Start: 0, End: 9}, want: []chunk_models.ByteRange{{Start: 0, End: 9}, {Start: 20, End: 29}}},
+		{name: "adjacent", receivedRanges: []chunk_models.ByteRange{{Start: 0, End: 9}}, byteRange: chunk_models.ByteRange{Start: 10, End: 19}, want: []chunk_models.ByteRange{{Start: 0, End: 19}}},
+		{name: "overlapping", receivedRanges: []chunk_models.ByteRange{{Start: 0, End: 9}}, byteRange: chunk_models.ByteRange{Start: 5, End: 14}, want: []chunk_models.ByteRange{{Start: 0, End: 14}}},
+		{name: "fills the gap", receivedRanges: []chunk_models.ByteRange{{Start: 0, End: 9}, {Start: 20, End: 29}}, byteRange: chunk_models.ByteRange{Start: 10, End: 19}, want: []chunk_models.ByteRange{{Start: 0, End: 29}}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			merged := mergeRanges(test.receivedRanges, test.byteRange)
+			if !slices.Equal(merged, test.want) {
+				t.Errorf("merged = %+v, want %+v", merged, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package helpers
+
+import (
+	chunk_models "ImageUploadMiniIo/pkg/image_chunks/models"
+	miniio "ImageUploadMiniIo/pkg/mini_io"
+	"ImageUploadMiniIo/pkg/session_store"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log"
+	"os"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Init() function to check the staging mode is used with shared stores and to remove the staged objects of the
+// expired sessions, as the object store does not expire them.
+// This includes the completed multipart uploads, which are kept under the staging prefix until processed.
+func init() {
+	// A chunk staged by one node can only be assembled on another if both the sessions and the objects are shared.
+	if IsStagingMode() && (!session_store.IsSharedStore() || !miniio.IsSharedObjectStore()) {
+		log.Fatalf("Error: Staging upload mode requires the redis session store and the mini-io object store.")
+		os.Exit(1)
+	}
+
+	// The handler may be called for the same session on every node, removing the staged objects is safe to repeat.
+	session_store.AddExpiryHandler(func(sessionId string) {
+		_, err := miniio.RemoveStagedObjects(sessionId)
+		if err != nil {
+			log.Printf("Error: Could not remove staged objects of session id \"%s\": %s", sessionId, err.Error())
+		}
+	})
+}
+
+// Function to check whether the chunks and ranges should be staged in the object store, instead of being saved in
+// the temporary folder of the node receiving them, so that the upload can be assembled on any node.
+func IsStagingMode() bool {
+	// Loading the environment variables.
+	return os.Getenv("CHUNK_UPLOAD_MODE") == "staging"
+}
+
+// Function to stage a chunk of a particular session in the object store.
+func saveChunkStaged(c *gin.Context, sessionData *chunk_models.SessionData, chunkDetails *chunk_models.RequestData) error {
+	// Get the file from the request.
+	file, err := c.FormFile("file")
+	if err != nil {
+		return err
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	// Stage the chunk, while hashing the bytes being sent.
+	hasher := sha256.New()
+	err = miniio.StageSessionChunk(sessionData.SessionId, chunkDetails.ChunkNumber, io.TeeReader(src, hasher), file.Size)
+	if err != nil {
+		return err
+	}
+
+	// If the client has sent a checksum for the chunk, verify it against the sent bytes.
+	// On mismatch, remove the corrupted chunk so that it is not compiled into the final file.
+	expectedChecksum := getChunkChecksum(chunkDetails)
+	if expectedChecksum != "" && expectedChecksum != hex.EncodeToString(hasher.Sum(nil)) {
+		miniio.RemoveStagedChunk(sessionData.SessionId, chunkDetails.ChunkNumber)
+		return ErrChunkChecksumMismatch
+	}
+
+	return nil
+}
+
+// Function to stage the body as a byte range of a particular session in the object store.
+// The body is first written to a temporary file, so that the bytes received before the body ends early are staged
+// as a shorter range and the checksum of the body is verified before anything is staged.
+func saveRangeStaged(sessionData *chunk_models.SessionData, byteRange *chunk_models.ByteRange, body io.Reader, bodyChecksum *BodyChecksum) (int64, error) {
+	bodyFile, err := os.CreateTemp("", sessionData.SessionId+"-*")
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(bodyFile.Name())
+	defer bodyFile.Close()
+
+	written, copyErr := copyRange(bodyFile, byteRange, body)
+	if written == 0 || (bodyChecksum != nil && (copyErr != nil || !bodyChecksum.matches())) {
+		return 0, copyErr
+	}
+
+	_, err = bodyFile.Seek(0, io.SeekStart)
+	if err != nil {
+		return 0, err
+	}
+
+	// Stage the bytes received, as a range ending where the body has ended.
+	receivedRange := chunk_models.ByteRange{Start: byteRange.Start, End: byteRange.Start + written - 1}
+	err = miniio.StageSessionRange(sessionData.SessionId, receivedRange, bodyFile)
+	if err != nil {
+		return 0, err
+	}
+
+	return written, copyErr
+}
@@ -0,0 +1,235 @@
+package helpers
+
+import (
+	chunk_models "ImageUploadMiniIo/pkg/image_chunks/models"
+	"ImageUploadMiniIo/pkg/lease"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"hash"
+	"log"
+	"mime"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// Version of the tus resumable upload protocol supported by the server.
+const TusVersion = "1.0.0"
+
+// Extensions of the tus resumable upload protocol supported by the server.
+const TusExtensions = "creation,termination,checksum"
+
+// Algorithms of the tus checksum extension supported by the server.
+const TusChecksumAlgorithms = "sha1,sha256,md5"
+
+// Status code replied by the tus checksum extension when the body does not match the checksum sent by the client.
+const StatusChecksumMismatch = 460
+
+// Error returned when the Upload-Checksum header of a tus PATCH request names an algorithm which is not supported.
+var ErrUnsupportedChecksumAlgorithm = errors.New("checksum algorithm is not supported")
+
+// Error returned when the Upload-Offset of a tus PATCH request does not match the offset of the upload.
+var ErrTusOffsetMismatch = errors.New("upload offset does not match the current offset of the upload")
+
+// Error returned when another tus PATCH request of the same upload is still being appended.
+var ErrTusUploadLocked = errors.New("another append of the upload is in progress")
+
+// Time the lock of a tus upload is held for, renewed for as long as the append is in progress.
+const tusLockTTL = 30 * time.Second
+
+// Function to lock a tus upload for an append, so that the appends of an upload are written one at a time on any node.
+// Returns the function releasing the lock.
+func LockTusUpload(sessionId string) (func(), error) {
+	uploadLease, err := lease.Acquire("tus_upload:"+sessionId, tusLockTTL)
+	if errors.Is(err, lease.ErrLeaseHeld) {
+		return nil, ErrTusUploadLocked
+	} else if err != nil {
+		return nil, err
+	}
+	stopKeepAlive := lease.KeepAlive(uploadLease)
+
+	return func() {
+		stopKeepAlive()
+		if err := lease.Release(uploadLease); err != nil {
+			log.Printf("Error: Could not release lease \"%s\": %s", uploadLease.Name, err.Error())
+		}
+	}, nil
+}
+
+// Function to get the maximum size of a tus upload in bytes, zero meaning no limit.
+func GetTusMaxSize() int64 {
+	// Loading the environment variables.
+	maxSize, err := strconv.ParseInt(os.Getenv("TUS_MAX_SIZE"), 10, 64)
+	if err != nil || maxSize < 0 {
+		return 0
+	}
+
+	return maxSize
+}
+
+// Function to parse the tus Upload-Metadata header, a comma separated list of keys and base64 encoded values.
+func ParseTusMetadata(uploadMetadata string) (map[string]string, error) {
+	metadata := make(map[string]string)
+	for _, pair := range strings.Split(uploadMetadata, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+
+		// The value is optional, a key on its own carries an empty value.
+		key, encodedValue, _ := strings.Cut(pair, " ")
+		value, err := base64.StdEncoding.DecodeString(encodedValue)
+		if err != nil {
+			return nil, fmt.Errorf("invalid upload metadata value for key \"%s\"", key)
+		}
+		metadata[key] = string(value)
+	}
+
+	return metadata, nil
+}
+
+// Function to get the file type of a tus upload from its metadata.
+// The file type is the extension of the file name, or the extension of the declared mime type.
+func getTusFileType(metadata map[string]string) string {
+	if fileType := strings.TrimPrefix(filepath.Ext(metadata["filename"]), "."); fileType != "" {
+		return fileType
+	}
+
+	if extensions, err := mime.ExtensionsByType(metadata["filetype"]); err == nil && len(extensions) > 0 {
+		return strings.TrimPrefix(extensions[0], ".")
+	}
+
+	return "bin"
+}
+
+// Function to create a new upload session for a tus creation request.
+func CreateTusSession(c *gin.Context) (*chunk_models.SessionData, error) {
+	// Get the length of the upload, deferring the length is not supported.
+	uploadLength, err := strconv.ParseInt(c.GetHeader("Upload-Length"), 10, 64)
+	if err != nil || uploadLength < 0 {
+		return nil, fmt.Errorf("upload length should be a number not less than zero")
+	}
+	if maxSize := GetTusMaxSize(); maxSize > 0 && uploadLength > maxSize {
+		return nil, fmt.Errorf("upload length exceeds the maximum size of %d bytes", maxSize)
+	}
+
+	metadata, err := ParseTusMetadata(c.GetHeader("Upload-Metadata"))
+	if err != nil {
+		return nil, err
+	}
+
+	// Set the file details from the upload length and metadata.
+	var fileDetails chunk_models.FileDetails
+	fileDetails.FileName = metadata["filename"]
+	fileDetails.FileType = getTusFileType(metadata)
+	if err := ValidateFileType(fileDetails.FileType); err != nil {
+		return nil, err
+	}
+	fileDetails.FileSizeUnit = "B"
+	fileDetails.FileSize = int(uploadLength)
+	fileDetails.FileChecksum = strings.ToLower(strings.TrimSpace(metadata["checksum"]))
+	if stripMetadata, err := strconv.ParseBool(metadata["strip_metadata"]); err == nil {
+		fileDetails.StripMetadata = &stripMetadata
+	}
+
+	// Create the session data and write it to the session store.
+	sessionData := newSessionData(uuid.NewString(), c.ClientIP(), c.Request.UserAgent(), fileDetails, time.Now())
+	sessionData.TotalBytes = uploadLength
+	err = saveSessionData(sessionData)
+	if err != nil {
+		return nil, err
+	}
+
+	return sessionData, nil
+}
+
+// Function to get the current offset of a tus upload, the number of contiguous bytes received from the start.
+func GetTusOffset(sessionData *chunk_models.SessionData) int64 {
+	if len(sessionData.ReceivedRanges) == 0 || sessionData.ReceivedRanges[0].Start != 0 {
+		return 0
+	}
+
+	return sessionData.ReceivedRanges[0].End + 1
+}
+
+// Function to check whether all the bytes of a tus upload have been received, which an empty upload is from its creation.
+func IsTusUploadComplete(sessionData *chunk_models.SessionData) bool {
+	return GetTusOffset(sessionData) == sessionData.TotalBytes
+}
+
+// Function to get the checksum a tus PATCH request has sent for its body in the Upload-Checksum header, of the form
+// "algorithm base64-digest". Returns nil when the request carries no checksum.
+func GetTusChecksum(c *gin.Context) (*BodyChecksum, error) {
+	uploadChecksum := c.GetHeader("Upload-Checksum")
+	if uploadChecksum == "" {
+		return nil, nil
+	}
+
+	algorithm, encodedDigest, found := strings.Cut(uploadChecksum, " ")
+	if !found {
+		return nil, fmt.Errorf("upload checksum should be of the form \"algorithm digest\"")
+	}
+
+	var hasher hash.Hash
+	switch algorithm {
+	case "sha1":
+		hasher = sha1.New()
+	case "sha256":
+		hasher = sha256.New()
+	case "md5":
+		hasher = md5.New()
+	default:
+		return nil, ErrUnsupportedChecksumAlgorithm
+	}
+
+	expected, err := base64.StdEncoding.DecodeString(encodedDigest)
+	if err != nil || len(expected) != hasher.Size() {
+		return nil, fmt.Errorf("upload checksum digest should be the base64 encoded %s digest", algorithm)
+	}
+
+	var bodyChecksum BodyChecksum
+	bodyChecksum.Hasher = hasher
+	bodyChecksum.Expected = expected
+
+	return &bodyChecksum, nil
+}
+
+// Function to get the byte range a tus PATCH request appends to the upload.
+// Returns nil when the request carries no bytes.
+func GetTusPatchRange(c *gin.Context, sessionData *chunk_models.SessionData) (*chunk_models.ByteRange, error) {
+	// The offset sent by the client should match the current offset of the upload.
+	uploadOffset, err := strconv.ParseInt(c.GetHeader("Upload-Offset"), 10, 64)
+	if err != nil || uploadOffset < 0 {
+		return nil, fmt.Errorf("upload offset should be a number not less than zero")
+	}
+	if uploadOffset != GetTusOffset(sessionData) {
+		return nil, ErrTusOffsetMismatch
+	}
+
+	// The body should not go past the length of the upload.
+	contentLength := c.Request.ContentLength
+	if contentLength < 0 {
+		return nil, fmt.Errorf("content length is required")
+	}
+	if uploadOffset+contentLength > sessionData.TotalBytes {
+		return nil, fmt.Errorf("request body exceeds the upload length")
+	}
+	if contentLength == 0 {
+		return nil, nil
+	}
+
+	var byteRange chunk_models.ByteRange
+	byteRange.Start = uploadOffset
+	byteRange.End = uploadOffset + contentLength - 1
+
+	return &byteRange, nil
+}
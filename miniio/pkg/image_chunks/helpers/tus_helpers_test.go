@@ -0,0 +1,242 @@
+package helpers
+
+import (
+	"ImageUploadMiniIo/pkg/chunk_manager"
+	chunk_models "ImageUploadMiniIo/pkg/image_chunks/models"
+	miniio "ImageUploadMiniIo/pkg/mini_io"
+	"bytes"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Function to create a gin context for a request with the given headers and body.
+func newTestContext(headers map[string]string, body io.Reader) *gin.Context {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodPatch, "/files/session", body)
+	for key, value := range headers {
+		c.Request.Header.Set(key, value)
+	}
+
+	return c
+}
+
+// Function to get the Upload-Checksum header of the given body for an algorithm.
+func uploadChecksum(algorithm string, digest []byte) string {
+	return algorithm + " " + base64.StdEncoding.EncodeToString(digest)
+}
+
+func TestCreateTusSessionFileType(t *testing.T) {
+	tests := []struct {
+		name         string
+		filename     string
+		filetype     string
+		wantFileType string
+		wantErr      bool
+	}{
+		{name: "extension of the file name", filename: "image.png", wantFileType: "png"},
+		{name: "extension of the mime type", filetype: "image/gif", wantFileType: "gif"},
+		{name: "no file name or mime type", wantFileType: "bin"},
+		{name: "extension with a space", filename: "image.p ng", wantErr: true},
+		{name: "extension with a separator", filename: `image.png\..\..\x`, wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			metadata := "filename " + base64.StdEncoding.EncodeToString([]byte(test.filename)) + ",filetype " + base64.StdEncoding.EncodeToString([]byte(test.filetype))
+			c := newTestContext(map[string]string{"Upload-Length": "10", "Upload-Metadata": metadata}, nil)
+
+			sessionData, err := CreateTusSession(c)
+			if test.wantErr {
+				if err != ErrInvalidFileType {
+					t.Fatalf("error = %v, want %v", err, ErrInvalidFileType)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			defer DeleteAllForSession(sessionData.SessionId)
+
+			if sessionData.FileType != test.wantFileType {
+				t.Errorf("file type = %s, want %s", sessionData.FileType, test.wantFileType)
+			}
+		})
+	}
+}
+
+func TestGetTusChecksum(t *testing.T) {
+	sha1Digest := sha1.Sum([]byte("hello"))
+	sha256Digest := sha256.Sum256([]byte("hello"))
+
+	tests := []struct {
+		name        string
+		header      string
+		wantNil     bool
+		wantMatches bool
+		wantErr     error
+	}{
+		{name: "no checksum", header: "", wantNil: true},
+		{name: "sha1", header: uploadChecksum("sha1", sha1Digest[:]), wantMatches: true},
+		{name: "sha256", header: uploadChecksum("sha256", sha256Digest[:]), wantMatches: true},
+		{name: "sha1 of other bytes", header: uploadChecksum("sha1", make([]byte, sha1.Size)), wantMatches: false},
+		{name: "unsupported algorithm", header: "crc32 AAAAAA==", wantErr: ErrUnsupportedChecksumAlgorithm},
+		{name: "digest of the wrong size", header: uploadChecksum("sha256", sha1Digest[:]), wantErr: errors.New("any")},
+		{name: "digest not base64", header: "sha1 !!!", wantErr: errors.New("any")},
+		{name: "no digest", header: "sha1", wantErr: errors.New("any")},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := newTestContext(map[string]string{"Upload-Checksum": test.header}, nil)
+
+			bodyChecksum, err := GetTusChecksum(c)
+			if test.wantErr != nil {
+				if err == nil {
+					t.Fatalf("expected an error")
+				}
+				if errors.Is(test.wantErr, ErrUnsupportedChecksumAlgorithm) && !errors.Is(err, ErrUnsupportedChecksumAlgorithm) {
+					t.Errorf("error = %v, want %v", err, ErrUnsupportedChecksumAlgorithm)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if test.wantNil {
+				if bodyChecksum != nil {
+					t.Errorf("checksum = %+v, want none", bodyChecksum)
+				}
+				return
+			}
+
+			bodyChecksum.Hasher.Write([]byte("hello"))
+			if bodyChecksum.matches() != test.wantMatches {
+				t.Errorf("matches = %v, want %v", bodyChecksum.matches(), test.wantMatches)
+			}
+		})
+	}
+}
+
+func TestSaveRange(t *testing.T) {
+	t.Setenv("FOLDER_TEMP_PATH", t.TempDir())
+	t.Setenv("OBJECT_STORE", "memory")
+
+	helloDigest := sha1.Sum([]byte("hello"))
+
+	for _, staged := range []bool{false, true} {
+		// The bodies are read by the test, so they are created again for every mode.
+		tests := []struct {
+			name        string
+			body        io.Reader
+			checksum    string
+			wantWritten int64
+			wantErr     bool
+			wantErrIs   error
+		}{
+			{name: "whole range", body: strings.NewReader("hello"), wantWritten: 5},
+			{name: "body ended early", body: strings.NewReader("hel"), wantWritten: 3, wantErr: true},
+			{name: "body interrupted", body: io.MultiReader(strings.NewReader("he"), iotest.ErrReader(errors.New("connection reset"))), wantWritten: 2, wantErr: true},
+			{name: "nothing received", body: iotest.ErrReader(errors.New("connection reset")), wantWritten: 0, wantErr: true},
+			{name: "checksum matches", body: strings.NewReader("hello"), checksum: uploadChecksum("sha1", helloDigest[:]), wantWritten: 5},
+			{name: "checksum mismatch", body: strings.NewReader("hellp"), checksum: uploadChecksum("sha1", helloDigest[:]), wantWritten: 0, wantErr: true, wantErrIs: ErrBodyChecksumMismatch},
+			{name: "interrupted with checksum", body: strings.NewReader("hel"), checksum: uploadChecksum("sha1", helloDigest[:]), wantWritten: 0, wantErr: true},
+		}
+
+		for i, test := range tests {
+			name := test.name
+			if staged {
+				name = "staged " + name
+			}
+
+			t.Run(name, func(t *testing.T) {
+				var sessionData chunk_models.SessionData
+				sessionData.SessionId = strings.ReplaceAll(name, " ", "-")
+				sessionData.FileType = "bin"
+				sessionData.Staged = staged
+				byteRange := chunk_models.ByteRange{Start: int64(i) * 10, End: int64(i)*10 + 4}
+
+				c := newTestContext(map[string]string{"Upload-Checksum": test.checksum}, test.body)
+				bodyChecksum, err := GetTusChecksum(c)
+				if err != nil {
+					t.Fatalf("could not parse checksum: %v", err)
+				}
+
+				written, err := SaveRange(c, &sessionData, &byteRange, bodyChecksum)
+				if written != test.wantWritten {
+					t.Errorf("written = %d, want %d", written, test.wantWritten)
+				}
+				if (err != nil) != test.wantErr {
+					t.Fatalf("error = %v, want error %v", err, test.wantErr)
+				}
+				if test.wantErrIs != nil && !errors.Is(err, test.wantErrIs) {
+					t.Errorf("error = %v, want %v", err, test.wantErrIs)
+				}
+
+				// The bytes kept should be the ones written from the start of the range.
+				wantData := []byte("hello")[:test.wantWritten]
+				var data []byte
+				if staged {
+					stagedRanges, err := miniio.ListStagedRanges(sessionData.SessionId)
+					if err != nil {
+						t.Fatalf("could not list staged ranges: %v", err)
+					}
+					if test.wantWritten == 0 {
+						if len(stagedRanges) != 0 {
+							t.Errorf("staged ranges = %v, want none", stagedRanges)
+						}
+						return
+					}
+					if len(stagedRanges) != 1 || stagedRanges[0].Start != byteRange.Start || stagedRanges[0].End != byteRange.Start+test.wantWritten-1 {
+						t.Fatalf("staged ranges = %v, want one ending after %d bytes", stagedRanges, test.wantWritten)
+					}
+					reader, err := miniio.GetStagedRangeReader(sessionData.SessionId, stagedRanges[0])
+					if err != nil {
+						t.Fatalf("could not read staged range: %v", err)
+					}
+					defer reader.Close()
+					data, _ = io.ReadAll(reader)
+				} else {
+					if test.wantWritten == 0 {
+						return
+					}
+					fileData, err := os.ReadFile(chunk_manager.GetRangeFilePath(sessionData.SessionId, sessionData.FileType))
+					if err != nil {
+						t.Fatalf("could not read range file: %v", err)
+					}
+					data = fileData[byteRange.Start : byteRange.Start+test.wantWritten]
+				}
+				if !bytes.Equal(data, wantData) {
+					t.Errorf("data = %q, want %q", data, wantData)
+				}
+			})
+		}
+	}
+}
+
+func TestLockTusUpload(t *testing.T) {
+	unlock, err := LockTusUpload("locked")
+	if err != nil {
+		t.Fatalf("could not lock upload: %v", err)
+	}
+	if _, err := LockTusUpload("locked"); err != ErrTusUploadLocked {
+		t.Errorf("second lock error = %v, want %v", err, ErrTusUploadLocked)
+	}
+	unlock()
+
+	unlock, err = LockTusUpload("locked")
+	if err != nil {
+		t.Fatalf("could not lock upload after unlock: %v", err)
+	}
+	unlock()
+}
@@ -2,6 +2,7 @@ package middleware
 
 import (
 	chunk_helpers "ImageUploadMiniIo/pkg/image_chunks/helpers"
+	"ImageUploadMiniIo/pkg/job_manager"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -10,9 +11,13 @@ import (
 func Authenticate() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Check whether the session id has been passed inside the cookie or not.
-		// If not set the empty string for session id and pass the control to the route handler.
+		// If not, check for the session id header returned by the session creation endpoint.
+		// If neither is present set the empty string for session id and pass the control to the route handler.
 		sessionId, err := c.Cookie("session_id")
 		if err != nil || sessionId == "" {
+			sessionId = c.GetHeader("X-Session-Id")
+		}
+		if sessionId == "" {
 			c.Set("sessionId", "")
 		} else {
 			// If session id is present inside the cookie, validate the session id.
@@ -34,6 +39,71 @@ func Authenticate() gin.HandlerFunc {
 			c.Set("sessionId", sessionId)
 		}
 
+		// The routes addressing a session by its id in the path can only be used by the client holding that session.
+		pathSessionId := c.Param("session_id")
+		if pathSessionId != "" && sessionId == "" {
+			c.JSON(http.StatusUnauthorized, gin.H{"message": "Invalid credentials."})
+			c.Abort()
+			return
+		}
+		if pathSessionId != "" && pathSessionId != sessionId {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Forbidden.", "error_details": "session id does not match the authenticated session"})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+func AuthenticateSessionOrJob() gin.HandlerFunc {
+	authenticate := Authenticate()
+
+	return func(c *gin.Context) {
+		// While the session exists, authenticate it as on any other route.
+		sessionId := c.Param("session_id")
+		exists, err := chunk_helpers.ValidateSession(sessionId)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error."})
+			c.Abort()
+			return
+		}
+		if exists {
+			authenticate(c)
+			return
+		}
+
+		// Once the session has been cleaned up, only its finalize job is left, which is looked up the same way it is polled.
+		job, err := job_manager.GetJob(sessionId)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error."})
+			c.Abort()
+			return
+		}
+		if job == nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Session not found."})
+			c.Abort()
+			return
+		}
+
+		c.Set("sessionId", "")
+		c.Next()
+	}
+}
+
+func TusResumable() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Every tus response carries the version of the protocol used by the server.
+		c.Header("Tus-Resumable", chunk_helpers.TusVersion)
+
+		// Except for the discovery request, the client should use the same version of the protocol.
+		if c.Request.Method != http.MethodOptions && c.GetHeader("Tus-Resumable") != chunk_helpers.TusVersion {
+			c.Header("Tus-Version", chunk_helpers.TusVersion)
+			c.JSON(http.StatusPreconditionFailed, gin.H{"error": "Unsupported tus version."})
+			c.Abort()
+			return
+		}
+
 		c.Next()
 	}
 }
@@ -0,0 +1,116 @@
+package middleware
+
+import (
+	chunk_models "ImageUploadMiniIo/pkg/image_chunks/models"
+	"ImageUploadMiniIo/pkg/job_manager"
+	job_models "ImageUploadMiniIo/pkg/job_manager/models"
+	"ImageUploadMiniIo/pkg/session_store"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	mapset "github.com/deckarep/golang-set/v2"
+	"github.com/gin-gonic/gin"
+)
+
+// Function to create a session in the session store for the tests.
+func createTestSession(t *testing.T, sessionId string) {
+	t.Helper()
+
+	var sessionData chunk_models.SessionData
+	sessionData.SessionId = sessionId
+	sessionData.ReceivedIds = mapset.NewSet[int]()
+	err := session_store.GetSessionStore().Create(&sessionData, time.Minute)
+	if err != nil {
+		t.Fatalf("could not create session: %v", err)
+	}
+	t.Cleanup(func() { session_store.GetSessionStore().Delete(sessionId) })
+}
+
+func TestAuthenticatePathSessionId(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	createTestSession(t, "session-a")
+	createTestSession(t, "session-b")
+
+	router := gin.New()
+	router.Use(Authenticate())
+	router.GET("/uploads/:session_id", func(c *gin.Context) { c.Status(http.StatusOK) })
+	router.GET("/uploads", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	tests := []struct {
+		name       string
+		path       string
+		header     string
+		cookie     string
+		wantStatus int
+	}{
+		{name: "matching header", path: "/uploads/session-a", header: "session-a", wantStatus: http.StatusOK},
+		{name: "matching cookie", path: "/uploads/session-a", cookie: "session-a", wantStatus: http.StatusOK},
+		{name: "another session", path: "/uploads/session-a", header: "session-b", wantStatus: http.StatusForbidden},
+		{name: "no credentials", path: "/uploads/session-a", wantStatus: http.StatusUnauthorized},
+		{name: "unknown session", path: "/uploads/session-a", header: "session-c", wantStatus: http.StatusUnauthorized},
+		{name: "no session in path", path: "/uploads", wantStatus: http.StatusOK},
+		{name: "no session in path with credentials", path: "/uploads", header: "session-b", wantStatus: http.StatusOK},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodGet, test.path, nil)
+			if test.header != "" {
+				request.Header.Set("X-Session-Id", test.header)
+			}
+			if test.cookie != "" {
+				request.AddCookie(&http.Cookie{Name: "session_id", Value: test.cookie})
+			}
+			recorder := httptest.NewRecorder()
+
+			router.ServeHTTP(recorder, request)
+
+			if recorder.Code != test.wantStatus {
+				t.Errorf("status = %d, want %d", recorder.Code, test.wantStatus)
+			}
+		})
+	}
+}
+
+func TestAuthenticateSessionOrJob(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	createTestSession(t, "session-live")
+	createTestSession(t, "session-other")
+	if _, err := job_manager.EnqueueFinalizeJob("session-finalized", job_models.JobSourceChunks); err != nil {
+		t.Fatalf("could not enqueue job: %v", err)
+	}
+
+	router := gin.New()
+	router.GET("/uploads/:session_id/events", AuthenticateSessionOrJob(), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	tests := []struct {
+		name       string
+		path       string
+		header     string
+		wantStatus int
+	}{
+		{name: "live session", path: "/uploads/session-live/events", header: "session-live", wantStatus: http.StatusOK},
+		{name: "live session of another client", path: "/uploads/session-live/events", header: "session-other", wantStatus: http.StatusForbidden},
+		{name: "live session without credentials", path: "/uploads/session-live/events", wantStatus: http.StatusUnauthorized},
+		{name: "cleaned up session with a job", path: "/uploads/session-finalized/events", wantStatus: http.StatusOK},
+		{name: "unknown session", path: "/uploads/session-unknown/events", wantStatus: http.StatusNotFound},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodGet, test.path, nil)
+			if test.header != "" {
+				request.Header.Set("X-Session-Id", test.header)
+			}
+			recorder := httptest.NewRecorder()
+
+			router.ServeHTTP(recorder, request)
+
+			if recorder.Code != test.wantStatus {
+				t.Errorf("status = %d, want %d", recorder.Code, test.wantStatus)
+			}
+		})
+	}
+}
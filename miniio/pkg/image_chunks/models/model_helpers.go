@@ -0,0 +1,69 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	mapset "github.com/deckarep/golang-set/v2"
+)
+
+// Function to deserialise the session data, giving the received ids set a concrete value to be deserialised into.
+func (sessionData *SessionData) UnmarshalJSON(data []byte) error {
+	// The alias type drops this method, so that the default deserialisation is used for the rest of the fields.
+	type sessionDataAlias SessionData
+	alias := sessionDataAlias{ReceivedIds: mapset.NewSet[int]()}
+	err := json.Unmarshal(data, &alias)
+	if err != nil {
+		return err
+	}
+
+	*sessionData = SessionData(alias)
+	if sessionData.ReceivedIds == nil {
+		sessionData.ReceivedIds = mapset.NewSet[int]()
+	}
+
+	return nil
+}
+
+// Function to get the number of bytes in the file size unit declared by the client.
+func (fileDetails FileDetails) SizeUnitInBytes() (int64, error) {
+	switch strings.ToUpper(strings.TrimSpace(fileDetails.FileSizeUnit)) {
+	case "", "B", "BYTE", "BYTES":
+		return 1, nil
+	case "KB":
+		return 1 << 10, nil
+	case "MB":
+		return 1 << 20, nil
+	case "GB":
+		return 1 << 30, nil
+	default:
+		return 0, fmt.Errorf("unknown file size unit \"%s\"", fileDetails.FileSizeUnit)
+	}
+}
+
+// Function to get the file size declared by the client in bytes.
+func (fileDetails FileDetails) SizeInBytes() (int64, error) {
+	unitInBytes, err := fileDetails.SizeUnitInBytes()
+	if err != nil {
+		return 0, err
+	}
+
+	return int64(fileDetails.FileSize) * unitInBytes, nil
+}
+
+// Function to check whether a size in bytes agrees with the file size declared by the client.
+// As the declared size is rounded to its unit, a difference smaller than one unit is accepted.
+func (fileDetails FileDetails) MatchesSize(sizeInBytes int64) (bool, error) {
+	unitInBytes, err := fileDetails.SizeUnitInBytes()
+	if err != nil {
+		return false, err
+	}
+
+	difference := int64(fileDetails.FileSize)*unitInBytes - sizeInBytes
+	if difference < 0 {
+		difference = -difference
+	}
+
+	return difference < unitInBytes, nil
+}
@@ -7,30 +7,80 @@ import (
 )
 
 type RequestData struct {
+	FileName      string `json:"file_name" form:"file_name" header:"X-File-Name"`
+	FileType      string `json:"file_type" form:"file_type" header:"X-File-Type"`
+	FileSizeUnit  string `json:"file_size_unit" form:"file_size_unit" header:"X-File-Size-Unit"`
+	FileSize      int    `json:"file_size" form:"file_size" header:"X-File-Size"`
+	TotalChunks   int    `json:"total_chunks" form:"total_chunks" header:"X-Total-Chunks"`
+	FileChecksum  string `json:"file_checksum" form:"file_checksum" header:"X-File-Checksum"`
+	ChunkNumber   int    `json:"chunk_number" form:"chunk_number" header:"X-Chunk-Number"`
+	ChunkChecksum string `json:"chunk_checksum" form:"chunk_checksum" header:"X-Chunk-Checksum"`
+	CompileStatus bool   `json:"compile_status" form:"compile_status" header:"X-Compile-Status"`
+}
+
+type FileDetails struct {
 	FileName      string `json:"file_name"`
 	FileType      string `json:"file_type"`
 	FileSizeUnit  string `json:"file_size_unit"`
 	FileSize      int    `json:"file_size"`
 	TotalChunks   int    `json:"total_chunks"`
-	ChunkNumber   int    `json:"chunk_number"`
-	CompileStatus bool   `json:"compile_status"`
+	FileChecksum  string `json:"file_checksum,omitempty"`
+	StripMetadata *bool  `json:"strip_metadata,omitempty"`
 }
 
-type FileDetails struct {
-	FileName     string `json:"file_name"`
-	FileType     string `json:"file_type"`
-	FileSizeUnit string `json:"file_size_unit"`
-	FileSize     int    `json:"file_size"`
-	TotalChunks  int    `json:"total_chunks"`
+type SessionData struct {
+	SessionId         string `json:"session_id"`
+	IPAddress         string `json:"ip_address"`
+	UserAgent         string `json:"user_agent"`
+	FileDetails       `json:"file_details"`
+	CreationTime      time.Time       `json:"creation_time"`
+	ExpiryTime        time.Time       `json:"expiry_time"`
+	FailedChunksInfo  []int           `json:"failed_chunks_info"`
+	ChunkFailCounts   map[int]int     `json:"chunk_fail_counts"`
+	ReceivedIds       mapset.Set[int] `json:"received_ids"`
+	TotalBytes        int64           `json:"total_bytes"`
+	ReceivedRanges    []ByteRange     `json:"received_ranges"`
+	MultipartUploadId string          `json:"multipart_upload_id,omitempty"`
+	MultipartParts    map[int]string  `json:"multipart_parts,omitempty"`
+	Staged            bool            `json:"staged,omitempty"`
 }
 
-type SessionData struct {
-	SessionId        string `json:"session_id"`
-	IPAddress        string `json:"ip_address"`
-	UserAgent        string `json:"user_agent"`
-	FileDetails      `json:"file_details"`
-	CreationTime     time.Time       `json:"creation_time"`
-	ExpiryTime       time.Time       `json:"expiry_time"`
-	FailedChunksInfo []int           `json:"failed_chunks_info"`
-	ReceivedIds      mapset.Set[int] `json:"received_ids"`
+type ByteRange struct {
+	Start int64 `json:"start"`
+	End   int64 `json:"end"`
+}
+
+type ChunkPlan struct {
+	TotalChunks  int   `json:"total_chunks"`
+	ChunkSize    int64 `json:"chunk_size"`
+	ChunkNumbers []int `json:"chunk_numbers"`
+}
+
+type UploadSessionResponse struct {
+	SessionId  string    `json:"session_id"`
+	ChunkPlan  ChunkPlan `json:"chunk_plan"`
+	ExpiryTime time.Time `json:"expiry_time"`
+}
+
+type UploadStatusResponse struct {
+	SessionId        string      `json:"session_id"`
+	FileDetails      FileDetails `json:"file_details"`
+	ReceivedIds      []int       `json:"received_ids"`
+	MissingIds       []int       `json:"missing_ids"`
+	FailedChunksInfo []int       `json:"failed_chunks_info"`
+	ChunkFailCounts  map[int]int `json:"chunk_fail_counts"`
+	MaxChunkRetries  int         `json:"max_chunk_retries"`
+	TotalBytes       int64       `json:"total_bytes"`
+	ReceivedRanges   []ByteRange `json:"received_ranges"`
+	TTLSeconds       int64       `json:"ttl_seconds"`
+	ExpiryTime       time.Time   `json:"expiry_time"`
+}
+
+type SessionResources struct {
+	SessionId     string `json:"session_id"`
+	RedisEntry    bool   `json:"redis_entry"`
+	TempFolder    bool   `json:"temp_folder"`
+	ChunkFiles    int    `json:"chunk_files"`
+	PermFolder    bool   `json:"perm_folder"`
+	StagedObjects int    `json:"staged_objects"`
 }
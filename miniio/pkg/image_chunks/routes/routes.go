@@ -8,6 +8,28 @@ import (
 )
 
 func ChunkRoutes(chunkRouter *gin.Engine) {
-	chunkRouter.Use(chunk_middleware.Authenticate())
-	chunkRouter.POST("/api/v1/upload_chunk", chunk_controller.UploadChunks())
+	// The session is authenticated on these routes only, the tus uploads are addressed by their upload url instead.
+	apiRouter := chunkRouter.Group("/api/v1")
+	apiRouter.Use(chunk_middleware.Authenticate())
+	apiRouter.POST("/uploads", chunk_controller.CreateUploadSession())
+	apiRouter.GET("/uploads/:session_id", chunk_controller.GetUploadStatus())
+	apiRouter.PUT("/uploads/:session_id", chunk_controller.UploadRange())
+	apiRouter.DELETE("/uploads/:session_id", chunk_controller.AbortUpload())
+	apiRouter.POST("/upload_chunk", chunk_controller.UploadChunks())
+	apiRouter.GET("/images/similar", chunk_controller.FindSimilarImages())
+
+	// The finalize job is polled after the session has been cleaned up, so it cannot be authenticated against the session.
+	// The progress events replay the finalize job in the same case, and are authenticated against the session while it exists.
+	chunkRouter.GET("/api/v1/uploads/:session_id/job", chunk_controller.GetFinalizeJob())
+	chunkRouter.GET("/api/v1/uploads/:session_id/events", chunk_middleware.AuthenticateSessionOrJob(), chunk_controller.StreamUploadEvents())
+}
+
+func TusRoutes(chunkRouter *gin.Engine) {
+	tusRouter := chunkRouter.Group("/api/v1/tus")
+	tusRouter.Use(chunk_middleware.TusResumable())
+	tusRouter.OPTIONS("", chunk_controller.TusOptions())
+	tusRouter.POST("", chunk_controller.TusCreate())
+	tusRouter.HEAD("/:session_id", chunk_controller.TusHead())
+	tusRouter.PATCH("/:session_id", chunk_controller.TusPatch())
+	tusRouter.DELETE("/:session_id", chunk_controller.TusDelete())
 }
@@ -0,0 +1,361 @@
+package job_manager
+
+import (
+	"ImageUploadMiniIo/pkg/chunk_manager"
+	"ImageUploadMiniIo/pkg/file_processor"
+	file_models "ImageUploadMiniIo/pkg/file_processor/models"
+	chunk_helpers "ImageUploadMiniIo/pkg/image_chunks/helpers"
+	job_models "ImageUploadMiniIo/pkg/job_manager/models"
+	"ImageUploadMiniIo/pkg/lease"
+	lease_models "ImageUploadMiniIo/pkg/lease/models"
+	miniio "ImageUploadMiniIo/pkg/mini_io"
+	"ImageUploadMiniIo/pkg/progress"
+	progress_models "ImageUploadMiniIo/pkg/progress/models"
+	"ImageUploadMiniIo/pkg/similarity"
+	"ImageUploadMiniIo/pkg/webhook"
+	webhook_models "ImageUploadMiniIo/pkg/webhook/models"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"time"
+)
+
+// Time a finalize job is kept in the job store, so that its status can be polled after the session is gone.
+const jobTTL = 24 * time.Hour
+
+// Error returned when the finalize job of a session is already being enqueued or run by another request.
+var ErrFinalizeInProgress = errors.New("upload is already being finalized")
+
+// Function to get the job manager.
+func GetJobManager() *job_models.JobManager {
+	return &jobManager
+}
+
+// Function to get the name of the lease held while the finalize job of a particular session id is enqueued or run.
+func getFinalizeLeaseName(sessionId string) string {
+	return "finalize_job:" + sessionId
+}
+
+// Function to get the TTL of the finalize lease, renewed for as long as the job runs.
+func getFinalizeLeaseTTL() time.Duration {
+	// Loading the environment variables.
+	ttlSeconds, err := strconv.Atoi(os.Getenv("FINALIZE_LEASE_TTL"))
+	if err != nil || ttlSeconds <= 0 {
+		return 30 * time.Second
+	}
+
+	return time.Duration(ttlSeconds) * time.Second
+}
+
+// Function to release the finalize lease, logging the failure as the lease expires by itself anyway.
+func releaseFinalizeLease(finalizeLease *lease_models.Lease) {
+	if err := lease.Release(finalizeLease); err != nil {
+		log.Printf("Error: Could not release lease \"%s\": %s", finalizeLease.Name, err.Error())
+	}
+}
+
+// Function to get the finalize job for a particular session id, nil if there is none.
+func GetJob(sessionId string) (*job_models.Job, error) {
+	return getJobStore().GetJob(sessionId)
+}
+
+// Function to write the finalize job to the job store.
+// The write is fenced by the lease held for the job, so that an owner which has lost the lease cannot overwrite it.
+func saveJob(job *job_models.Job) error {
+	job.UpdateTime = time.Now()
+
+	return getJobStore().SaveJob(job, jobTTL)
+}
+
+// Function to enqueue the finalize job of a particular session, assembling the file from the given source.
+// If the session already has a job which is done or queued, that job is returned instead of enqueueing a new one,
+// a queued job being pushed to the queue again in case it was lost. A job being run while its lease is free has been
+// left behind by a worker which stopped, so it is enqueued again.
+// If another request is enqueueing or running the job, the current job is returned along with ErrFinalizeInProgress.
+func EnqueueFinalizeJob(sessionId string, source string) (*job_models.Job, error) {
+	// Take the finalize lease, so that the requests completing the upload at the same time enqueue a single job.
+	finalizeLease, err := lease.Acquire(getFinalizeLeaseName(sessionId), getFinalizeLeaseTTL())
+	if errors.Is(err, lease.ErrLeaseHeld) {
+		existingJob, err := GetJob(sessionId)
+		if err != nil {
+			return nil, err
+		}
+		return existingJob, ErrFinalizeInProgress
+	} else if err != nil {
+		return nil, err
+	}
+	defer releaseFinalizeLease(finalizeLease)
+
+	// Check whether the session already has a job.
+	existingJob, err := GetJob(sessionId)
+	if err != nil {
+		return nil, err
+	}
+	if existingJob != nil && existingJob.State == job_models.JobDone {
+		return existingJob, nil
+	}
+	// A duplicate push is skipped by the worker, as the job is no longer queued once it has been run.
+	if existingJob != nil && existingJob.State == job_models.JobQueued {
+		err = getJobStore().PushJob(sessionId)
+		if err != nil {
+			return nil, err
+		}
+		return existingJob, nil
+	}
+	if existingJob != nil && existingJob.State != job_models.JobFailed {
+		log.Printf("Message: Requeueing finalize job of session id \"%s\" left in state \"%s\"", sessionId, existingJob.State)
+	}
+
+	// Write the job and push the session id to the queue.
+	var job job_models.Job
+	job.SessionId = sessionId
+	job.Source = source
+	job.State = job_models.JobQueued
+	job.CreationTime = time.Now()
+	job.Lease = finalizeLease
+	err = saveJob(&job)
+	if err != nil {
+		return nil, err
+	}
+
+	err = getJobStore().PushJob(sessionId)
+	if err != nil {
+		return nil, err
+	}
+
+	return &job, nil
+}
+
+// Function to update the state of a finalize job, recording the error if any.
+// Returns lease.ErrLeaseLost when the lease of the job has been lost, in which case the job should not go any further.
+func setJobState(job *job_models.Job, state string, err error) error {
+	job.State = state
+	if err != nil {
+		job.Error = err.Error()
+	}
+
+	saveErr := saveJob(job)
+	if errors.Is(saveErr, lease.ErrLeaseLost) {
+		log.Printf("Error: Lease of finalize job of session id \"%s\" has been lost, leaving it to the new owner.", job.SessionId)
+		return saveErr
+	} else if saveErr != nil {
+		log.Printf("Error: Could not save finalize job of session id \"%s\": %s", job.SessionId, saveErr.Error())
+	}
+
+	// Publish the progress of the job to the clients following the session.
+	eventData := map[string]any{"job": job}
+	switch state {
+	case job_models.JobAssembling:
+		progress.Publish(progress_models.EventAssembling, job.SessionId, eventData)
+	case job_models.JobProcessing:
+		progress.Publish(progress_models.EventProcessing, job.SessionId, eventData)
+	case job_models.JobStoring:
+		progress.Publish(progress_models.EventStoring, job.SessionId, eventData)
+	case job_models.JobDone:
+		progress.Publish(progress_models.EventCompleted, job.SessionId, eventData)
+	}
+
+	return nil
+}
+
+// Function to mark a finalize job as failed and notify the webhooks about the stage it failed in.
+// A job whose session has been abandoned ends the progress of the session, otherwise the session is kept so that the
+// client can trigger the job again.
+func failJob(job *job_models.Job, err error, abandoned bool) {
+	stage := job.State
+	if errors.Is(setJobState(job, job_models.JobFailed, err), lease.ErrLeaseLost) {
+		return
+	}
+
+	eventData := map[string]any{"job": job, "abandoned": abandoned}
+	if abandoned {
+		progress.Publish(progress_models.EventFailed, job.SessionId, eventData)
+	} else {
+		progress.Publish(progress_models.EventFinalizeFailed, job.SessionId, eventData)
+	}
+
+	webhook.Send(webhook_models.EventUploadFailed, job.SessionId, map[string]any{
+		"stage":     stage,
+		"error":     err.Error(),
+		"abandoned": abandoned,
+	})
+}
+
+// Function to put a finalize job back at the end of the queue, after a short wait so that it is not picked straight away.
+func requeueJob(sessionId string) {
+	select {
+	case <-jobManager.Ctx.Done():
+	case <-time.After(time.Second):
+	}
+
+	err := getJobStore().PushJob(sessionId)
+	if err != nil {
+		log.Printf("Error: Could not requeue finalize job of session id \"%s\": %s", sessionId, err.Error())
+	}
+}
+
+// Function to assemble the file of a particular session, store it in the mini-io bucket and clean up the session.
+// The finalize lease is held for as long as the job runs, so that exactly one worker across the nodes finalizes the session.
+func runFinalizeJob(sessionId string) {
+	job, err := GetJob(sessionId)
+	if err != nil {
+		log.Printf("Error: Could not get finalize job of session id \"%s\": %s", sessionId, err.Error())
+		return
+	}
+	if job == nil || job.State != job_models.JobQueued {
+		return
+	}
+
+	// Take the finalize lease. If it is held, the job is either still being enqueued or already picked by another
+	// worker, so it is put back in the queue and skipped once it is picked again if it is no longer queued.
+	finalizeLease, err := lease.Acquire(getFinalizeLeaseName(sessionId), getFinalizeLeaseTTL())
+	if errors.Is(err, lease.ErrLeaseHeld) {
+		requeueJob(sessionId)
+		return
+	} else if err != nil {
+		log.Printf("Error: Could not acquire lease of finalize job of session id \"%s\": %s", sessionId, err.Error())
+		requeueJob(sessionId)
+		return
+	}
+	defer releaseFinalizeLease(finalizeLease)
+	stopKeepAlive := lease.KeepAlive(finalizeLease)
+	defer stopKeepAlive()
+
+	// Read the job again now that the lease is held, as another worker may have run it in the meantime.
+	job, err = GetJob(sessionId)
+	if err != nil {
+		log.Printf("Error: Could not get finalize job of session id \"%s\": %s", sessionId, err.Error())
+		return
+	}
+	if job == nil || job.State != job_models.JobQueued {
+		return
+	}
+	job.Lease = finalizeLease
+
+	// Assemble the file from its source.
+	if errors.Is(setJobState(job, job_models.JobAssembling, nil), lease.ErrLeaseLost) {
+		return
+	}
+
+	var fileChecksum string
+	switch job.Source {
+	case job_models.JobSourceRanges:
+		fileChecksum, err = chunk_manager.CompileRanges(sessionId)
+	default:
+		fileChecksum, err = chunk_manager.CompileChunks(sessionId)
+	}
+	job.FileChecksum = fileChecksum
+
+	// If the assembled file does not match the expected checksum, the session cannot be recovered.
+	// On any other error, the session is kept so that the client can trigger the job again.
+	if errors.Is(err, chunk_manager.ErrFileChecksumMismatch) {
+		chunk_helpers.DeleteAllForSession(sessionId)
+		failJob(job, err, true)
+		return
+	} else if err != nil {
+		failJob(job, err, false)
+		return
+	}
+
+	// Check and process the assembled file before it is stored.
+	if errors.Is(setJobState(job, job_models.JobProcessing, nil), lease.ErrLeaseLost) {
+		return
+	}
+
+	sessionData, _, err := chunk_helpers.GetSessionData(sessionId)
+	if err != nil {
+		failJob(job, err, false)
+		return
+	}
+
+	// If the file is rejected, the session cannot be recovered. If the chunks have been streamed into a
+	// multipart upload, the rejected object is removed from the bucket as well.
+	fileReport, err := file_processor.ProcessSessionFile(sessionData, fileChecksum)
+	if errors.Is(err, file_processor.ErrFileRejected) {
+		// An infected file is kept under the quarantine prefix along with its verdict, if configured so.
+		var infectedErr *file_processor.InfectedFileError
+		if errors.As(err, &infectedErr) {
+			job.FileReport = &file_models.FileReport{FileChecksum: fileChecksum, Scan: infectedErr.ScanResult}
+			if file_processor.IsQuarantineEnabled() {
+				if _, quarantineErr := miniio.QuarantineSessionFile(sessionData, job.FileReport); quarantineErr != nil {
+					log.Printf("Error: Could not quarantine file of session id \"%s\": %s", sessionId, quarantineErr.Error())
+				}
+			}
+		}
+		if sessionData.MultipartUploadId != "" {
+			miniio.RemoveSessionMultipartObject(sessionId)
+		}
+		chunk_helpers.DeleteAllForSession(sessionId)
+		failJob(job, err, true)
+		return
+	} else if err != nil {
+		failJob(job, err, false)
+		return
+	}
+	job.FileReport = fileReport
+
+	// Run the mini-io and transfer the files into s3 buckets.
+	if errors.Is(setJobState(job, job_models.JobStoring, nil), lease.ErrLeaseLost) {
+		return
+	}
+
+	objectName, err := miniio.UploadSessionFilesToMiniIoBucket(sessionId, fileReport)
+	if err != nil {
+		failJob(job, err, false)
+		return
+	}
+	job.ObjectName = objectName
+	job.Deduplicated = objectName != sessionId
+
+	// Index the perceptual hash of the stored object, so that its near duplicates can be found later.
+	// The object is already stored, so a failure is only logged.
+	if fileReport.PerceptualHash != "" {
+		if err := similarity.IndexObject(objectName, fileReport.PerceptualHash); err != nil {
+			log.Printf("Error: Could not index perceptual hash of object \"%s\": %s", objectName, err.Error())
+		}
+	}
+
+	// Notify the webhooks about the stored object, while the session data is still around.
+	eventData := map[string]any{
+		"bucket_name":  miniio.GetMiniIoClient().BucketName,
+		"object_name":  job.ObjectName,
+		"file_details": sessionData.FileDetails,
+		"file_report":  fileReport,
+		"deduplicated": job.Deduplicated,
+	}
+	webhook.Send(webhook_models.EventUploadStored, sessionId, eventData)
+
+	// Delete redis row item, temp folder and perm folder for that session id.
+	// The file is already stored, so the job is done even if the clean up fails.
+	errorList := chunk_helpers.DeleteAllForSession(sessionId)
+	if len(errorList) > 0 {
+		setJobState(job, job_models.JobDone, fmt.Errorf("clean up failed: %v", errorList))
+		return
+	}
+
+	setJobState(job, job_models.JobDone, nil)
+}
+
+// Function to pick the finalize jobs from the queue until the job manager is shut down.
+func handleJobs() {
+	defer jobManager.Wg.Done()
+
+	for {
+		sessionId, err := getJobStore().PopJob(jobManager.Ctx, 5*time.Second)
+		if jobManager.Ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			log.Printf("Error: Could not pick finalize job from the queue: %s", err.Error())
+			time.Sleep(time.Second)
+			continue
+		}
+		if sessionId == "" {
+			continue
+		}
+
+		runFinalizeJob(sessionId)
+	}
+}
@@ -0,0 +1,156 @@
+package job_manager
+
+import (
+	job_models "ImageUploadMiniIo/pkg/job_manager/models"
+	"ImageUploadMiniIo/pkg/lease"
+	"ImageUploadMiniIo/pkg/progress"
+	progress_models "ImageUploadMiniIo/pkg/progress/models"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// Function to replace the job store with an empty in-memory one, in place of the one created on first use.
+func useMemoryStore() *MemoryStore {
+	jobManager.StoreOnce.Do(func() {})
+	store := NewMemoryStore()
+	jobManager.Store = store
+
+	return store
+}
+
+// Function to write a finalize job in the given state, as left by a worker whose lease has since expired.
+func seedJob(t *testing.T, sessionId string, state string, creationTime time.Time) {
+	t.Helper()
+
+	finalizeLease, err := lease.Acquire(getFinalizeLeaseName(sessionId), time.Minute)
+	if err != nil {
+		t.Fatalf("could not acquire lease: %v", err)
+	}
+	defer lease.Release(finalizeLease)
+
+	job := job_models.Job{SessionId: sessionId, Source: job_models.JobSourceChunks, State: state, CreationTime: creationTime, Lease: finalizeLease}
+	if err := saveJob(&job); err != nil {
+		t.Fatalf("could not save job: %v", err)
+	}
+}
+
+func TestEnqueueFinalizeJob(t *testing.T) {
+	creationTime := time.Now().Add(-time.Hour).Truncate(time.Second)
+
+	tests := []struct {
+		name          string
+		existingState string
+		wantState     string
+		wantNewJob    bool
+	}{
+		{name: "no job", existingState: "", wantState: job_models.JobQueued, wantNewJob: true},
+		{name: "queued job is pushed again", existingState: job_models.JobQueued, wantState: job_models.JobQueued},
+		{name: "abandoned while assembling", existingState: job_models.JobAssembling, wantState: job_models.JobQueued, wantNewJob: true},
+		{name: "abandoned while processing", existingState: job_models.JobProcessing, wantState: job_models.JobQueued, wantNewJob: true},
+		{name: "abandoned while storing", existingState: job_models.JobStoring, wantState: job_models.JobQueued, wantNewJob: true},
+		{name: "failed job is retried", existingState: job_models.JobFailed, wantState: job_models.JobQueued, wantNewJob: true},
+		{name: "done job is kept", existingState: job_models.JobDone, wantState: job_models.JobDone},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			store := useMemoryStore()
+
+			sessionId := "session-" + test.name
+			if test.existingState != "" {
+				seedJob(t, sessionId, test.existingState, creationTime)
+			}
+
+			job, err := EnqueueFinalizeJob(sessionId, job_models.JobSourceChunks)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if job.State != test.wantState {
+				t.Errorf("state = %q, want %q", job.State, test.wantState)
+			}
+			if isNewJob := !job.CreationTime.Equal(creationTime); isNewJob != test.wantNewJob {
+				t.Errorf("new job = %v, want %v", isNewJob, test.wantNewJob)
+			}
+
+			storedJob, err := GetJob(sessionId)
+			if err != nil {
+				t.Fatalf("could not get job: %v", err)
+			}
+			if storedJob.State != test.wantState {
+				t.Errorf("stored state = %q, want %q", storedJob.State, test.wantState)
+			}
+
+			wantQueue := 1
+			if test.wantState == job_models.JobDone {
+				wantQueue = 0
+			}
+			if len(store.queue) != wantQueue {
+				t.Errorf("queue = %v, want %d entries", store.queue, wantQueue)
+			}
+		})
+	}
+}
+
+func TestEnqueueFinalizeJobWhileLeaseHeld(t *testing.T) {
+	useMemoryStore()
+	sessionId := "session-lease-held"
+
+	finalizeLease, err := lease.Acquire(getFinalizeLeaseName(sessionId), time.Minute)
+	if err != nil {
+		t.Fatalf("could not acquire lease: %v", err)
+	}
+	defer lease.Release(finalizeLease)
+
+	_, err = EnqueueFinalizeJob(sessionId, job_models.JobSourceChunks)
+	if err != ErrFinalizeInProgress {
+		t.Errorf("error = %v, want %v", err, ErrFinalizeInProgress)
+	}
+}
+
+func TestFailJob(t *testing.T) {
+	tests := []struct {
+		name          string
+		abandoned     bool
+		wantEventType string
+	}{
+		{name: "recoverable", abandoned: false, wantEventType: progress_models.EventFinalizeFailed},
+		{name: "abandoned", abandoned: true, wantEventType: progress_models.EventFailed},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			useMemoryStore()
+			sessionId := "session-failed-" + test.name
+
+			finalizeLease, err := lease.Acquire(getFinalizeLeaseName(sessionId), time.Minute)
+			if err != nil {
+				t.Fatalf("could not acquire lease: %v", err)
+			}
+			defer lease.Release(finalizeLease)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			events, err := progress.Subscribe(ctx, sessionId)
+			if err != nil {
+				t.Fatalf("could not subscribe: %v", err)
+			}
+
+			job := job_models.Job{SessionId: sessionId, Source: job_models.JobSourceChunks, State: job_models.JobAssembling, Lease: finalizeLease}
+			failJob(&job, errors.New("failed"), test.abandoned)
+
+			select {
+			case event := <-events:
+				if event.EventType != test.wantEventType {
+					t.Errorf("event type = %q, want %q", event.EventType, test.wantEventType)
+				}
+				if progress.IsFinal(event.EventType) != test.abandoned {
+					t.Errorf("final = %v, want %v", progress.IsFinal(event.EventType), test.abandoned)
+				}
+			case <-time.After(time.Second):
+				t.Fatal("no event published")
+			}
+		})
+	}
+}
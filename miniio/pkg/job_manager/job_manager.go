@@ -0,0 +1,54 @@
+package job_manager
+
+import (
+	job_models "ImageUploadMiniIo/pkg/job_manager/models"
+	redis_database "ImageUploadMiniIo/pkg/redis"
+	"context"
+	"log"
+	"os"
+	"strconv"
+)
+
+// Declaring a job manager variable.
+var jobManager job_models.JobManager
+
+// Function to start the workers finalizing the uploads in the background, called once the environment is loaded.
+func Start() {
+	// Getting the number of workers, defaulting to two.
+	workers, err := strconv.Atoi(os.Getenv("FINALIZE_WORKERS"))
+	if err != nil || workers <= 0 {
+		workers = 2
+	}
+
+	// Once Do is used to make sure only one time this code within is executed in case of multi-threaded excution.
+	jobManager.Once.Do(func() {
+		// Set job manager context, cancelled when the job manager is shut down.
+		jobManager.Ctx, jobManager.Cancel = context.WithCancel(context.Background())
+		jobManager.Workers = workers
+
+		// Starting the go routines picking the finalize jobs from the queue.
+		for i := 0; i < workers; i++ {
+			jobManager.Wg.Add(1)
+			go handleJobs()
+		}
+
+		log.Printf("Message: Started %d finalize job workers.", workers)
+	})
+}
+
+// Function to create the job store selected by configuration, called once by the first function asking for it.
+func connectJobStore() {
+	// Set the job store, the redis when configured so that the jobs can be picked by any node.
+	if redis_database.IsConfigured() {
+		jobManager.Store = NewRedisStore()
+	} else {
+		jobManager.Store = NewMemoryStore()
+	}
+}
+
+// Function to get the store the finalize jobs and their queue are kept in, creating it the first time it is called.
+func getJobStore() job_models.JobStore {
+	jobManager.StoreOnce.Do(connectJobStore)
+
+	return jobManager.Store
+}
@@ -0,0 +1,140 @@
+package job_manager
+
+import (
+	job_models "ImageUploadMiniIo/pkg/job_manager/models"
+	"ImageUploadMiniIo/pkg/lease"
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// Interval at which the memory store removes the finalize jobs kept for longer than their TTL.
+const jobSweepInterval = time.Minute
+
+// Job store keeping the finalize jobs and their queue in memory, meant for single node deployments and tests.
+type MemoryStore struct {
+	mutex     sync.Mutex
+	jobs      map[string]*memoryJob
+	queue     []string
+	notify    chan struct{}
+	lastSweep time.Time
+}
+
+// Finalize job held in a memory store, kept as json so that the callers never share the job held by the store.
+type memoryJob struct {
+	jsonData   []byte
+	expiryTime time.Time
+}
+
+// Function to create an empty job store in memory.
+func NewMemoryStore() *MemoryStore {
+	var store MemoryStore
+	store.jobs = make(map[string]*memoryJob)
+	store.notify = make(chan struct{}, 1)
+	store.lastSweep = time.Now()
+
+	return &store
+}
+
+// Function to wake up a worker waiting for a job, unless one is already being woken up.
+func (store *MemoryStore) wakeWorker() {
+	select {
+	case store.notify <- struct{}{}:
+	default:
+	}
+}
+
+// Function to get the finalize job for a particular session id from memory, nil if there is none.
+func (store *MemoryStore) GetJob(sessionId string) (*job_models.Job, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	storedJob, ok := store.jobs[sessionId]
+	if !ok || !time.Now().Before(storedJob.expiryTime) {
+		return nil, nil
+	}
+
+	var job job_models.Job
+	err := json.Unmarshal(storedJob.jsonData, &job)
+	if err != nil {
+		return nil, err
+	}
+
+	return &job, nil
+}
+
+// Function to write the finalize job into memory, rejected with lease.ErrLeaseLost once a newer fencing token
+// has been issued for the lease held for the job.
+func (store *MemoryStore) SaveJob(job *job_models.Job, ttl time.Duration) error {
+	jsonData, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	err = lease.CheckToken(job.Lease)
+	if err != nil {
+		return err
+	}
+
+	// Remove the expired jobs, at most once every interval.
+	now := time.Now()
+	if now.Sub(store.lastSweep) >= jobSweepInterval {
+		store.lastSweep = now
+		for sessionId, storedJob := range store.jobs {
+			if !now.Before(storedJob.expiryTime) {
+				delete(store.jobs, sessionId)
+			}
+		}
+	}
+
+	store.jobs[job.SessionId] = &memoryJob{jsonData: jsonData, expiryTime: now.Add(ttl)}
+
+	return nil
+}
+
+// Function to push the finalize job of a particular session id to the queue in memory.
+func (store *MemoryStore) PushJob(sessionId string) error {
+	store.mutex.Lock()
+	store.queue = append(store.queue, sessionId)
+	store.mutex.Unlock()
+
+	store.wakeWorker()
+
+	return nil
+}
+
+// Function to pick the session id of the next finalize job from the queue in memory, waiting for one up to the timeout.
+// Returns an empty session id if no job has been queued in the meantime.
+func (store *MemoryStore) PopJob(ctx context.Context, timeout time.Duration) (string, error) {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	for {
+		store.mutex.Lock()
+		if len(store.queue) > 0 {
+			sessionId := store.queue[0]
+			store.queue = store.queue[1:]
+			remaining := len(store.queue)
+			store.mutex.Unlock()
+
+			// Pass the wake up on to the next worker, as more jobs are waiting.
+			if remaining > 0 {
+				store.wakeWorker()
+			}
+			return sessionId, nil
+		}
+		store.mutex.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-timer.C:
+			return "", nil
+		case <-store.notify:
+		}
+	}
+}
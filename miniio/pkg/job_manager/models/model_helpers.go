@@ -0,0 +1,12 @@
+package models
+
+func (jobManager *JobManager) ShutDown() {
+	// Nothing to stop if the job manager has not been started.
+	if jobManager.Cancel == nil {
+		return
+	}
+
+	jobManager.Cancel()
+
+	jobManager.Wg.Wait()
+}
@@ -0,0 +1,57 @@
+package models
+
+import (
+	file_models "ImageUploadMiniIo/pkg/file_processor/models"
+	lease_models "ImageUploadMiniIo/pkg/lease/models"
+	"context"
+	"sync"
+	"time"
+)
+
+// States a finalize job goes through.
+const (
+	JobQueued     = "queued"
+	JobAssembling = "assembling"
+	JobProcessing = "processing"
+	JobStoring    = "storing"
+	JobDone       = "done"
+	JobFailed     = "failed"
+)
+
+// Sources a finalize job assembles the file from.
+const (
+	JobSourceChunks = "chunks"
+	JobSourceRanges = "ranges"
+)
+
+// Interface of the backend the finalize jobs and their queue are kept in.
+type JobStore interface {
+	GetJob(sessionId string) (*Job, error)
+	SaveJob(job *Job, ttl time.Duration) error
+	PushJob(sessionId string) error
+	PopJob(ctx context.Context, timeout time.Duration) (string, error)
+}
+
+type JobManager struct {
+	Ctx       context.Context
+	Cancel    context.CancelFunc
+	Once      sync.Once
+	Wg        sync.WaitGroup
+	Workers   int
+	Store     JobStore
+	StoreOnce sync.Once
+}
+
+type Job struct {
+	SessionId    string                  `json:"session_id"`
+	Source       string                  `json:"source"`
+	State        string                  `json:"state"`
+	Error        string                  `json:"error,omitempty"`
+	FileChecksum string                  `json:"file_checksum,omitempty"`
+	FileReport   *file_models.FileReport `json:"file_report,omitempty"`
+	ObjectName   string                  `json:"object_name,omitempty"`
+	Deduplicated bool                    `json:"deduplicated"`
+	CreationTime time.Time               `json:"creation_time"`
+	UpdateTime   time.Time               `json:"update_time"`
+	Lease        *lease_models.Lease     `json:"-"`
+}
@@ -0,0 +1,84 @@
+package job_manager
+
+import (
+	job_models "ImageUploadMiniIo/pkg/job_manager/models"
+	"ImageUploadMiniIo/pkg/lease"
+	redis_database "ImageUploadMiniIo/pkg/redis"
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// Redis list the finalize jobs are queued in.
+const jobQueueKey = "finalize_job_queue"
+
+// Job store keeping the finalize jobs and their queue in the redis, so that any node can pick them.
+type RedisStore struct{}
+
+// Function to create a job store on the redis client.
+func NewRedisStore() *RedisStore {
+	return &RedisStore{}
+}
+
+// Function to get the redis key of the finalize job for a particular session id.
+func getJobKey(sessionId string) string {
+	return "finalize_job:" + sessionId
+}
+
+// Function to get the finalize job for a particular session id from the redis, nil if there is none.
+func (store *RedisStore) GetJob(sessionId string) (*job_models.Job, error) {
+	// Get the redis client.
+	redisClient := redis_database.GetRedisClient()
+
+	jsonData, err := redisClient.Client.Get(redisClient.Ctx, getJobKey(sessionId)).Result()
+	if err == redis.Nil {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var job job_models.Job
+	err = json.Unmarshal([]byte(jsonData), &job)
+	if err != nil {
+		return nil, err
+	}
+
+	return &job, nil
+}
+
+// Function to write the finalize job to the redis, fenced by the lease held for the job.
+func (store *RedisStore) SaveJob(job *job_models.Job, ttl time.Duration) error {
+	jsonData, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+
+	return lease.SetFenced(job.Lease, getJobKey(job.SessionId), jsonData, ttl)
+}
+
+// Function to push the finalize job of a particular session id to the queue in the redis.
+func (store *RedisStore) PushJob(sessionId string) error {
+	// Get the redis client.
+	redisClient := redis_database.GetRedisClient()
+
+	return redisClient.Client.LPush(redisClient.Ctx, jobQueueKey, sessionId).Err()
+}
+
+// Function to pick the session id of the next finalize job from the queue in the redis, waiting for one up to the timeout.
+// Returns an empty session id if no job has been queued in the meantime.
+func (store *RedisStore) PopJob(ctx context.Context, timeout time.Duration) (string, error) {
+	// Get the redis client.
+	redisClient := redis_database.GetRedisClient()
+
+	result, err := redisClient.Client.BRPop(ctx, timeout, jobQueueKey).Result()
+	if err == redis.Nil {
+		return "", nil
+	} else if err != nil {
+		return "", err
+	}
+
+	// The result holds the name of the list followed by the session id.
+	return result[1], nil
+}
@@ -0,0 +1,102 @@
+package lease
+
+import (
+	lease_models "ImageUploadMiniIo/pkg/lease/models"
+	redis_database "ImageUploadMiniIo/pkg/redis"
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Time the fencing token counter of a lease is kept after the lease was last acquired.
+const fencingTokenTTL = 24 * time.Hour
+
+// Error returned when the lease is already held by another owner.
+var ErrLeaseHeld = errors.New("lease is held by another owner")
+
+// Error returned when the lease has expired or has been taken over, so that the owner should stop what it protects.
+var ErrLeaseLost = errors.New("lease has been lost")
+
+// Declaring the lease store, the redis when configured so that the leases hold across the nodes.
+var leaseStore lease_models.LeaseStore
+
+// Init() function to select the lease store.
+func init() {
+	if redis_database.IsConfigured() {
+		leaseStore = NewRedisStore()
+	} else {
+		leaseStore = NewMemoryStore()
+	}
+}
+
+// Function to acquire a lease, held until it is released or its TTL runs out without being renewed.
+// Every acquisition is given a fencing token greater than the ones issued before, so that the writes of an owner
+// which has lost the lease can be rejected.
+func Acquire(name string, ttl time.Duration) (*lease_models.Lease, error) {
+	var lease lease_models.Lease
+	lease.Name = name
+	lease.Owner = uuid.NewString()
+	lease.TTL = ttl
+
+	token, err := leaseStore.Acquire(&lease)
+	if err != nil {
+		return nil, err
+	}
+	lease.Token = token
+
+	return &lease, nil
+}
+
+// Function to extend the expiry of a lease by its TTL.
+func Renew(lease *lease_models.Lease) error {
+	return leaseStore.Renew(lease)
+}
+
+// Function to release a lease, so that it can be acquired by another owner straight away.
+// A lease which has already been lost is left as it is.
+func Release(lease *lease_models.Lease) error {
+	return leaseStore.Release(lease)
+}
+
+// Function to check that no newer fencing token has been issued for a lease, returning ErrLeaseLost otherwise.
+func CheckToken(lease *lease_models.Lease) error {
+	return leaseStore.CheckToken(lease)
+}
+
+// Function to keep renewing a lease in the background, three times within its TTL, until the returned function is called.
+func KeepAlive(lease *lease_models.Lease) func() {
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		ticker := time.NewTicker(lease.TTL / 3)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				err := Renew(lease)
+				if errors.Is(err, ErrLeaseLost) {
+					log.Printf("Error: Lease \"%s\" has been lost.", lease.Name)
+					return
+				} else if err != nil {
+					log.Printf("Error: Could not renew lease \"%s\": %s", lease.Name, err.Error())
+				}
+			}
+		}
+	}()
+
+	return func() {
+		cancel()
+		wg.Wait()
+	}
+}
@@ -0,0 +1,49 @@
+package lease
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAcquire(t *testing.T) {
+	tests := []struct {
+		name    string
+		ttl     time.Duration
+		release bool
+		wantErr error
+	}{
+		{name: "held", ttl: time.Minute, wantErr: ErrLeaseHeld},
+		{name: "released", ttl: time.Minute, release: true},
+		{name: "expired", ttl: time.Millisecond},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			first, err := Acquire("test-"+t.Name(), test.ttl)
+			if err != nil {
+				t.Fatalf("could not acquire lease: %v", err)
+			}
+			defer Release(first)
+			if test.release {
+				Release(first)
+			}
+			time.Sleep(5 * time.Millisecond)
+
+			second, err := Acquire("test-"+t.Name(), time.Minute)
+			if err != test.wantErr {
+				t.Fatalf("error = %v, want %v", err, test.wantErr)
+			}
+			if second == nil {
+				return
+			}
+			defer Release(second)
+
+			if second.Token <= first.Token {
+				t.Errorf("token = %d, want greater than %d", second.Token, first.Token)
+			}
+			if err := Renew(first); err != ErrLeaseLost {
+				t.Errorf("renew of the previous owner error = %v, want %v", err, ErrLeaseLost)
+			}
+		})
+	}
+}
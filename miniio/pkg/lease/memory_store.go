@@ -0,0 +1,133 @@
+package lease
+
+import (
+	lease_models "ImageUploadMiniIo/pkg/lease/models"
+	"sync"
+	"time"
+)
+
+// Interval at which the memory store removes the fencing tokens which have not been issued for longer than their TTL.
+const fencingTokenSweepInterval = time.Minute
+
+// Lease store keeping the leases in memory, meant for single node deployments and tests as they only hold within the process.
+type MemoryStore struct {
+	mutex     sync.Mutex
+	leases    map[string]*memoryLease
+	tokens    map[string]*memoryToken
+	lastSweep time.Time
+}
+
+// Lease held in a memory store.
+type memoryLease struct {
+	owner      string
+	expiryTime time.Time
+}
+
+// Last fencing token issued for a lease held in a memory store.
+type memoryToken struct {
+	token      int64
+	expiryTime time.Time
+}
+
+// Function to create an empty lease store in memory.
+func NewMemoryStore() *MemoryStore {
+	var store MemoryStore
+	store.leases = make(map[string]*memoryLease)
+	store.tokens = make(map[string]*memoryToken)
+	store.lastSweep = time.Now()
+
+	return &store
+}
+
+// Function to get a lease which has not expired, nil if there is none. The mutex should be held by the caller.
+func (store *MemoryStore) getLease(name string) *memoryLease {
+	heldLease, ok := store.leases[name]
+	if !ok || !time.Now().Before(heldLease.expiryTime) {
+		return nil
+	}
+
+	return heldLease
+}
+
+// Function to remove the expired leases and fencing tokens, at most once every interval. The mutex should be held by the caller.
+func (store *MemoryStore) sweepExpired() {
+	now := time.Now()
+	if now.Sub(store.lastSweep) < fencingTokenSweepInterval {
+		return
+	}
+	store.lastSweep = now
+
+	for name, heldLease := range store.leases {
+		if !now.Before(heldLease.expiryTime) {
+			delete(store.leases, name)
+		}
+	}
+	for name, issuedToken := range store.tokens {
+		if !now.Before(issuedToken.expiryTime) {
+			delete(store.tokens, name)
+		}
+	}
+}
+
+// Function to acquire a lease in memory, returning its fencing token.
+func (store *MemoryStore) Acquire(lease *lease_models.Lease) (int64, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	store.sweepExpired()
+	if store.getLease(lease.Name) != nil {
+		return 0, ErrLeaseHeld
+	}
+
+	issuedToken, ok := store.tokens[lease.Name]
+	if !ok || !time.Now().Before(issuedToken.expiryTime) {
+		issuedToken = &memoryToken{}
+		store.tokens[lease.Name] = issuedToken
+	}
+	issuedToken.token++
+	issuedToken.expiryTime = time.Now().Add(fencingTokenTTL)
+
+	store.leases[lease.Name] = &memoryLease{owner: lease.Owner, expiryTime: time.Now().Add(lease.TTL)}
+
+	return issuedToken.token, nil
+}
+
+// Function to extend the expiry of a lease held in memory by its TTL.
+func (store *MemoryStore) Renew(lease *lease_models.Lease) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	heldLease := store.getLease(lease.Name)
+	if heldLease == nil || heldLease.owner != lease.Owner {
+		return ErrLeaseLost
+	}
+	heldLease.expiryTime = time.Now().Add(lease.TTL)
+
+	return nil
+}
+
+// Function to release a lease held in memory.
+func (store *MemoryStore) Release(lease *lease_models.Lease) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	heldLease := store.getLease(lease.Name)
+	if heldLease != nil && heldLease.owner == lease.Owner {
+		delete(store.leases, lease.Name)
+	}
+
+	return nil
+}
+
+// Function to check the fencing token of a lease against the last one issued in memory.
+func (store *MemoryStore) CheckToken(lease *lease_models.Lease) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	issuedToken, ok := store.tokens[lease.Name]
+	if ok && time.Now().Before(issuedToken.expiryTime) && lease.Token < issuedToken.token {
+		return ErrLeaseLost
+	}
+
+	return nil
+}
@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// Interface of the backend the leases are kept in.
+type LeaseStore interface {
+	Acquire(lease *Lease) (int64, error)
+	Renew(lease *Lease) error
+	Release(lease *Lease) error
+	CheckToken(lease *Lease) error
+}
+
+type Lease struct {
+	Name  string        `json:"name"`
+	Owner string        `json:"owner"`
+	Token int64         `json:"token"`
+	TTL   time.Duration `json:"ttl"`
+}
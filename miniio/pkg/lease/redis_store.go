@@ -0,0 +1,147 @@
+package lease
+
+import (
+	lease_models "ImageUploadMiniIo/pkg/lease/models"
+	redis_database "ImageUploadMiniIo/pkg/redis"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// Script to acquire a lease if nobody holds it, issuing the next fencing token of the lease.
+// KEYS: lease, fencing token counter. ARGV: owner, ttl in milliseconds, fencing token ttl in milliseconds.
+var acquireScript = redis.NewScript(`
+if not redis.call("SET", KEYS[1], ARGV[1], "NX", "PX", ARGV[2]) then
+	return false
+end
+local token = redis.call("INCR", KEYS[2])
+redis.call("PEXPIRE", KEYS[2], ARGV[3])
+return token
+`)
+
+// Script to extend the expiry of a lease, if it is still held by the owner.
+// KEYS: lease. ARGV: owner, ttl in milliseconds.
+var renewScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) ~= ARGV[1] then
+	return 0
+end
+return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+`)
+
+// Script to release a lease, if it is still held by the owner.
+// KEYS: lease. ARGV: owner.
+var releaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) ~= ARGV[1] then
+	return 0
+end
+return redis.call("DEL", KEYS[1])
+`)
+
+// Script to write a value, unless a newer fencing token has been issued for the lease protecting it.
+// KEYS: value, fencing token counter. ARGV: fencing token, value, ttl in milliseconds.
+var setFencedScript = redis.NewScript(`
+local current = tonumber(redis.call("GET", KEYS[2]) or "0")
+if tonumber(ARGV[1]) < current then
+	return 0
+end
+redis.call("SET", KEYS[1], ARGV[2], "PX", ARGV[3])
+return 1
+`)
+
+// Lease store keeping the leases in the redis, so that they hold across the nodes.
+type RedisStore struct{}
+
+// Function to create a lease store on the redis client.
+func NewRedisStore() *RedisStore {
+	return &RedisStore{}
+}
+
+// Function to get the redis key of a lease.
+func getLeaseKey(name string) string {
+	return "lease:" + name
+}
+
+// Function to get the redis key of the counter issuing the fencing tokens of a lease.
+func getFencingTokenKey(name string) string {
+	return "lease_fencing_token:" + name
+}
+
+// Function to acquire a lease in the redis, returning its fencing token.
+func (store *RedisStore) Acquire(lease *lease_models.Lease) (int64, error) {
+	// Get the redis client.
+	redisClient := redis_database.GetRedisClient()
+
+	token, err := acquireScript.Run(redisClient.Ctx, redisClient.Client, []string{getLeaseKey(lease.Name), getFencingTokenKey(lease.Name)},
+		lease.Owner, lease.TTL.Milliseconds(), fencingTokenTTL.Milliseconds()).Int64()
+	if err == redis.Nil {
+		return 0, ErrLeaseHeld
+	}
+
+	return token, err
+}
+
+// Function to extend the expiry of a lease held in the redis by its TTL.
+func (store *RedisStore) Renew(lease *lease_models.Lease) error {
+	// Get the redis client.
+	redisClient := redis_database.GetRedisClient()
+
+	renewed, err := renewScript.Run(redisClient.Ctx, redisClient.Client, []string{getLeaseKey(lease.Name)}, lease.Owner, lease.TTL.Milliseconds()).Int()
+	if err != nil {
+		return err
+	}
+	if renewed == 0 {
+		return ErrLeaseLost
+	}
+
+	return nil
+}
+
+// Function to release a lease held in the redis.
+func (store *RedisStore) Release(lease *lease_models.Lease) error {
+	// Get the redis client.
+	redisClient := redis_database.GetRedisClient()
+
+	return releaseScript.Run(redisClient.Ctx, redisClient.Client, []string{getLeaseKey(lease.Name)}, lease.Owner).Err()
+}
+
+// Function to check the fencing token of a lease against the last one issued by the redis.
+func (store *RedisStore) CheckToken(lease *lease_models.Lease) error {
+	// Get the redis client.
+	redisClient := redis_database.GetRedisClient()
+
+	current, err := redisClient.Client.Get(redisClient.Ctx, getFencingTokenKey(lease.Name)).Result()
+	if err == redis.Nil {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	currentToken, err := strconv.ParseInt(current, 10, 64)
+	if err != nil {
+		return err
+	}
+	if lease.Token < currentToken {
+		return ErrLeaseLost
+	}
+
+	return nil
+}
+
+// Function to write a redis key protected by a lease held in the redis, rejected with ErrLeaseLost once a newer
+// fencing token has been issued. The check and the write are done atomically by the redis.
+func SetFenced(lease *lease_models.Lease, key string, value []byte, ttl time.Duration) error {
+	// Get the redis client.
+	redisClient := redis_database.GetRedisClient()
+
+	written, err := setFencedScript.Run(redisClient.Ctx, redisClient.Client, []string{key, getFencingTokenKey(lease.Name)},
+		lease.Token, value, ttl.Milliseconds()).Int()
+	if err != nil {
+		return err
+	}
+	if written == 0 {
+		return ErrLeaseLost
+	}
+
+	return nil
+}
@@ -0,0 +1,79 @@
+package miniio
+
+import (
+	file_models "ImageUploadMiniIo/pkg/file_processor/models"
+	chunk_models "ImageUploadMiniIo/pkg/image_chunks/models"
+	"ImageUploadMiniIo/pkg/object_store"
+	"bytes"
+	"errors"
+	"os"
+)
+
+// Function to check whether the uploads are deduplicated by their checksum, disabled unless turned on.
+func isDedupEnabled() bool {
+	// Loading the environment variables.
+	return os.Getenv("DEDUP_ENABLED") == "true"
+}
+
+// Function to check whether a lightweight reference object is stored for a deduplicated upload.
+func isDedupReferenceEnabled() bool {
+	// Loading the environment variables.
+	return os.Getenv("DEDUP_REFERENCE_OBJECTS") == "true"
+}
+
+// Function to find the object already holding the content with a particular checksum, empty if there is none.
+// An index entry whose object is no longer in the bucket is removed from the index.
+func findObjectByChecksum(fileChecksum string) (string, error) {
+	if !isDedupEnabled() || fileChecksum == "" {
+		return "", nil
+	}
+
+	objectName, err := checksumIndex.GetObjectName(fileChecksum)
+	if err != nil || objectName == "" {
+		return "", err
+	}
+
+	_, err = GetObjectStore().StatObject(objectName)
+	if errors.Is(err, object_store.ErrObjectNotFound) {
+		return "", checksumIndex.RemoveObjectName(fileChecksum, objectName)
+	} else if err != nil {
+		return "", err
+	}
+
+	return objectName, nil
+}
+
+// Function to index the object holding the content with a particular checksum.
+func indexObjectChecksum(fileChecksum string, objectName string) error {
+	if !isDedupEnabled() || fileChecksum == "" {
+		return nil
+	}
+
+	return checksumIndex.SetObjectName(fileChecksum, objectName)
+}
+
+// Function to handle the upload of a session whose content is already held by another object.
+// If the chunks have been streamed into a multipart upload, the staged duplicate object is removed from the bucket.
+// If enabled, an empty object pointing to the existing one is stored under the name of the session.
+func deduplicateSessionFile(sessionData *chunk_models.SessionData, existingObjectName string, fileReport *file_models.FileReport) error {
+	if sessionData.MultipartUploadId != "" {
+		err := RemoveSessionMultipartObject(sessionData.SessionId)
+		if err != nil {
+			return err
+		}
+	}
+
+	if !isDedupReferenceEnabled() {
+		return nil
+	}
+
+	// Set metadata for the reference object, pointing to the existing object.
+	metaData := newObjectMetadata(sessionData, fileReport)
+	metaData.DuplicateOf = existingObjectName
+	metaDataMap, err := toUserMetadata(metaData)
+	if err != nil {
+		return err
+	}
+
+	return GetObjectStore().PutObject(sessionData.SessionId, bytes.NewReader(nil), 0, getObjectContentType(sessionData, fileReport), metaDataMap)
+}
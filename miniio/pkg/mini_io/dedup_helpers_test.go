@@ -0,0 +1,75 @@
+package miniio
+
+import (
+	miniio_models "ImageUploadMiniIo/pkg/mini_io/models"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestMain(m *testing.M) {
+	// Keep the objects in memory, the object store is created once by the first test asking for it.
+	os.Setenv("OBJECT_STORE", "memory")
+
+	os.Exit(m.Run())
+}
+
+// Function to store an object holding the given metadata, the way an upload stores it.
+func putTestObject(t *testing.T, objectName string, fileChecksum string) {
+	t.Helper()
+
+	var metaData miniio_models.Metadata
+	metaData.SessionId = objectName
+	metaData.FileChecksum = fileChecksum
+	metaDataMap, err := toUserMetadata(&metaData)
+	if err != nil {
+		t.Fatalf("could not build metadata: %v", err)
+	}
+
+	err = GetObjectStore().PutObject(objectName, strings.NewReader("content"), int64(len("content")), "image/png", metaDataMap)
+	if err != nil {
+		t.Fatalf("could not put object: %v", err)
+	}
+}
+
+func TestFindObjectByChecksum(t *testing.T) {
+	tests := []struct {
+		name           string
+		dedupEnabled   string
+		storeObject    bool
+		wantObjectName string
+		wantIndexed    bool
+	}{
+		{name: "disabled by default", dedupEnabled: "", storeObject: true, wantObjectName: "", wantIndexed: true},
+		{name: "disabled", dedupEnabled: "false", storeObject: true, wantObjectName: "", wantIndexed: true},
+		{name: "enabled", dedupEnabled: "true", storeObject: true, wantObjectName: "object-enabled", wantIndexed: true},
+		{name: "stale entry removed", dedupEnabled: "true", storeObject: false, wantObjectName: "", wantIndexed: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Setenv("DEDUP_ENABLED", test.dedupEnabled)
+			checksumIndex = NewMemoryChecksumIndex()
+
+			objectName := "object-" + strings.Fields(test.name)[0]
+			fileChecksum := "checksum-" + test.name
+			if test.storeObject {
+				putTestObject(t, objectName, fileChecksum)
+			}
+			checksumIndex.SetObjectName(fileChecksum, objectName)
+
+			foundObjectName, err := findObjectByChecksum(fileChecksum)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if foundObjectName != test.wantObjectName {
+				t.Errorf("object name = %q, want %q", foundObjectName, test.wantObjectName)
+			}
+
+			indexedObjectName, _ := checksumIndex.GetObjectName(fileChecksum)
+			if (indexedObjectName != "") != test.wantIndexed {
+				t.Errorf("indexed object name = %q, want indexed %v", indexedObjectName, test.wantIndexed)
+			}
+		})
+	}
+}
@@ -1,91 +1,189 @@
 package miniio
 
 import (
+	file_models "ImageUploadMiniIo/pkg/file_processor/models"
 	chunk_models "ImageUploadMiniIo/pkg/image_chunks/models"
 	miniio_models "ImageUploadMiniIo/pkg/mini_io/models"
-	redis_database "ImageUploadMiniIo/pkg/redis"
-	"context"
+	object_store_models "ImageUploadMiniIo/pkg/object_store/models"
+	"ImageUploadMiniIo/pkg/session_store"
 	"encoding/json"
 	"fmt"
 	"log"
 	"mime"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
-
-	"github.com/go-redis/redis/v8"
-	"github.com/minio/minio-go"
 )
 
-// Function to get the minio-io client.
+// Function to get the minio-io client, creating the object store the first time it is called.
 func GetMiniIoClient() *miniio_models.MiniIoClient {
+	// Once Do is used to make sure only one time this code within is executed in case of multi-threaded excution.
+	miniIoClient.Once.Do(connectObjectStore)
+
 	return &miniIoClient
 }
 
-// Function to upload files to mini-io bucket.
-func UploadSessionFilesToMiniIoBucket(sessionId string) error {
-	// Get the redis client.
-	redisClient := redis_database.GetRedisClient()
+// Function to get the object store the objects are kept in, creating it the first time it is called.
+func GetObjectStore() object_store_models.ObjectStore {
+	return GetMiniIoClient().Store
+}
 
+// Function to get the session data for a particular session id.
+func getSessionData(sessionId string) (*chunk_models.SessionData, error) {
 	// Get the chunk details for the particular session id.
-	jsonData, err := redisClient.Client.Get(redisClient.Ctx, sessionId).Result()
-	if err == redis.Nil {
-		return fmt.Errorf("session has expired")
-	} else if err != nil {
-		return err
-	}
-
-	// Unmarshal the jsonData.
-	var sessionData chunk_models.SessionData
-	err = json.Unmarshal([]byte(jsonData), &sessionData)
+	sessionData, _, err := session_store.GetSessionStore().Get(sessionId)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	// Get the metadata for the object.
-	var metaData miniio_models.Metadata
-	metaData.SessionId = sessionData.SessionId
-	metaData.IPAddress = sessionData.SessionId
-	metaData.UserAgent = sessionData.UserAgent
-	metaData.FileDetails = sessionData.FileDetails
-	metaData.CreationTime = time.Now()
+	return sessionData, nil
+}
 
+// Function to get the location of the compiled file of a particular session in the permanent folder.
+func getPermFilePath(sessionData *chunk_models.SessionData) string {
 	// Get the permanent folder location for the session id.
 	folderPermPath := os.Getenv("FOLDER_PERM_PATH")
-	folderPermPath = filepath.Join(folderPermPath, sessionId)
-	fileName := fmt.Sprintf("%s.%s", sessionId, sessionData.FileDetails.FileType)
-	filePermPath := filepath.Join(folderPermPath, "/"+fileName)
+	folderPermPath = filepath.Join(folderPermPath, sessionData.SessionId)
+	fileName := fmt.Sprintf("%s.%s", sessionData.SessionId, sessionData.FileDetails.FileType)
 
-	// Get the content type.
-	contentType := mime.TypeByExtension(sessionData.FileDetails.FileType)
+	return filepath.Join(folderPermPath, "/"+fileName)
+}
+
+// Function to get the content type of the object from the file type declared by the client.
+func getContentType(fileType string) string {
+	contentType := mime.TypeByExtension("." + strings.TrimPrefix(fileType, "."))
 	if contentType == "" {
 		contentType = "application/octet-stream"
 	}
 
-	// Set the object name.
-	objectName := sessionId
+	return contentType
+}
 
-	// Set metadata for the object.
+// Function to get the metadata of the object for a particular session.
+// The report of the compiled file is only known once it has been processed, and may be nil before that.
+func newObjectMetadata(sessionData *chunk_models.SessionData, fileReport *file_models.FileReport) *miniio_models.Metadata {
+	var metaData miniio_models.Metadata
+	metaData.SessionId = sessionData.SessionId
+	metaData.IPAddress = sessionData.IPAddress
+	metaData.UserAgent = sessionData.UserAgent
+	metaData.FileDetails = sessionData.FileDetails
+	metaData.CreationTime = time.Now()
+	if fileReport != nil {
+		metaData.FileChecksum = fileReport.FileChecksum
+		metaData.OriginalChecksum = fileReport.OriginalChecksum
+		metaData.MetadataStripped = fileReport.MetadataStripped
+		metaData.ContentType = fileReport.ContentType
+		metaData.Renditions = getRenditionObjectNames(fileReport)
+		metaData.Image = fileReport.Image
+		metaData.PerceptualHash = fileReport.PerceptualHash
+		metaData.Scan = fileReport.Scan
+	}
+
+	return &metaData
+}
+
+// Function to convert the metadata of the object into the user metadata stored along with it.
+func toUserMetadata(metaData *miniio_models.Metadata) (map[string]string, error) {
 	// Serialise the metadata into json.
 	metaDataJson, err := json.Marshal(metaData)
 	if err != nil {
-		return err
+		return nil, err
 	}
+
 	// Convert json to string.
 	metaDataMap := map[string]string{
-		fmt.Sprintf("%s_metadata", sessionId): string(metaDataJson),
+		fmt.Sprintf("%s_metadata", metaData.SessionId): string(metaDataJson),
+	}
+
+	return metaDataMap, nil
+}
+
+// Function to get the user metadata of the object for a particular session.
+func getObjectMetadata(sessionData *chunk_models.SessionData, fileReport *file_models.FileReport) (map[string]string, error) {
+	return toUserMetadata(newObjectMetadata(sessionData, fileReport))
+}
+
+// Function to get the content type of the object, preferring the one detected from the content of the file.
+func getObjectContentType(sessionData *chunk_models.SessionData, fileReport *file_models.FileReport) string {
+	if fileReport != nil && fileReport.ContentType != "" {
+		return fileReport.ContentType
+	}
+
+	return getContentType(sessionData.FileDetails.FileType)
+}
+
+// Function to upload files to mini-io bucket.
+// The report of the compiled file, holding its SHA-256 checksum, detected content type and renditions, is written into the object metadata.
+// If an object with the same checksum is already in the bucket, the file is not uploaded again.
+// Returns the name of the object holding the content of the file.
+func UploadSessionFilesToMiniIoBucket(sessionId string, fileReport *file_models.FileReport) (string, error) {
+	// Get the session data for the particular session id.
+	sessionData, err := getSessionData(sessionId)
+	if err != nil {
+		return "", err
+	}
+
+	// Set the object name.
+	objectName := sessionId
+
+	// Check whether the same content is already in the bucket.
+	existingObjectName, err := findObjectByChecksum(fileReport.FileChecksum)
+	if err != nil {
+		return "", err
+	}
+	if existingObjectName != "" && existingObjectName != objectName {
+		// The existing object already has its renditions, so the ones generated for this file are dropped.
+		fileReport.Renditions = nil
+		err = deduplicateSessionFile(sessionData, existingObjectName, fileReport)
+		if err != nil {
+			return "", err
+		}
+
+		log.Printf("Message: Skipped duplicate upload in Mini-Io server with session id \"%s\", content already in \"%s\"", sessionId, existingObjectName)
+
+		return existingObjectName, nil
 	}
 
-	// Upload the file.
-	_, err = miniIoClient.Client.FPutObjectWithContext(context.Background(), miniIoClient.BucketName, objectName, filePermPath, minio.PutObjectOptions{
-		ContentType:  contentType,
-		UserMetadata: metaDataMap,
-	})
+	// Upload the renditions first, so that their names are recorded in the metadata of the object.
+	err = uploadRenditions(objectName, fileReport)
+	if err != nil {
+		return "", err
+	}
+
+	// Set metadata for the object.
+	metaDataMap, err := getObjectMetadata(sessionData, fileReport)
 	if err != nil {
-		return err
+		return "", err
 	}
 
-	log.Printf("Message: Successfully uploaded object in Mini-Io server with session id \"%s\"", sessionId)
+	// If the chunks have been streamed into a multipart upload, the object is already in the bucket under the staging
+	// prefix and only needs to be copied to its final name along with the metadata.
+	if sessionData.MultipartUploadId != "" {
+		err = promoteSessionMultipartObject(sessionId, objectName, getObjectContentType(sessionData, fileReport), metaDataMap)
+		if err != nil {
+			return "", err
+		}
+
+		log.Printf("Message: Successfully moved object out of the staging prefix in Mini-Io server with session id \"%s\"", sessionId)
+	} else {
+		// Get the permanent file location for the session id.
+		filePermPath := getPermFilePath(sessionData)
+
+		// Upload the file.
+		err = putObjectFromFile(objectName, filePermPath, getObjectContentType(sessionData, fileReport), metaDataMap)
+		if err != nil {
+			return "", err
+		}
+
+		log.Printf("Message: Successfully uploaded object in Mini-Io server with session id \"%s\"", sessionId)
+	}
+
+	// Index the checksum of the object, so that later uploads of the same content are deduplicated.
+	err = indexObjectChecksum(fileReport.FileChecksum, objectName)
+	if err != nil {
+		return "", err
+	}
 
-	return nil
+	return objectName, nil
 }
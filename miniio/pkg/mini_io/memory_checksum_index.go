@@ -0,0 +1,44 @@
+package miniio
+
+import "sync"
+
+// Checksum index keeping the object name of every checksum in memory, meant for single node deployments and tests.
+type MemoryChecksumIndex struct {
+	mutex       sync.RWMutex
+	objectNames map[string]string
+}
+
+// Function to create an empty checksum index in memory.
+func NewMemoryChecksumIndex() *MemoryChecksumIndex {
+	return &MemoryChecksumIndex{objectNames: make(map[string]string)}
+}
+
+// Function to get the object indexed in memory under a particular checksum, empty if there is none.
+func (index *MemoryChecksumIndex) GetObjectName(fileChecksum string) (string, error) {
+	index.mutex.RLock()
+	defer index.mutex.RUnlock()
+
+	return index.objectNames[fileChecksum], nil
+}
+
+// Function to index an object in memory under a particular checksum.
+func (index *MemoryChecksumIndex) SetObjectName(fileChecksum string, objectName string) error {
+	index.mutex.Lock()
+	defer index.mutex.Unlock()
+
+	index.objectNames[fileChecksum] = objectName
+
+	return nil
+}
+
+// Function to remove an object from memory under a particular checksum, unless another object has been indexed since.
+func (index *MemoryChecksumIndex) RemoveObjectName(fileChecksum string, objectName string) error {
+	index.mutex.Lock()
+	defer index.mutex.Unlock()
+
+	if index.objectNames[fileChecksum] == objectName {
+		delete(index.objectNames, fileChecksum)
+	}
+
+	return nil
+}
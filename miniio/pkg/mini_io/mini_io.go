@@ -1,11 +1,16 @@
 package miniio
 
 import (
+	"errors"
+	"io/fs"
 	"log"
 	"os"
 	"strconv"
 
 	miniio_models "ImageUploadMiniIo/pkg/mini_io/models"
+	"ImageUploadMiniIo/pkg/object_store"
+	redis_database "ImageUploadMiniIo/pkg/redis"
+	"ImageUploadMiniIo/pkg/similarity"
 
 	"github.com/joho/godotenv"
 	"github.com/minio/minio-go"
@@ -14,14 +19,63 @@ import (
 // Declaring a mini-io client variable.
 var miniIoClient miniio_models.MiniIoClient
 
+// Declaring the index of the stored objects by their checksum, the redis when configured so that it is shared by the nodes.
+var checksumIndex miniio_models.ChecksumIndex
+
+// Init() function to load the environment variables, the object store itself is only created once it is needed.
 func init() {
-	// Loading the redis address and password environment variables.
+	// Loading the redis address and password environment variables, which may also be set without an environment file.
 	err := godotenv.Load(".env")
-	if err != nil {
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
 		log.Fatalf("Error: Problem while loading environment variables.")
 		os.Exit(1)
 	}
 
+	if redis_database.IsConfigured() {
+		checksumIndex = NewRedisChecksumIndex()
+	} else {
+		checksumIndex = NewMemoryChecksumIndex()
+	}
+
+	// The objects matched by a similarity search are checked to still be in the bucket.
+	similarity.SetObjectExistsCheck(ObjectExists)
+}
+
+// Function to create the object store selected by configuration, called once by the first function asking for it.
+func connectObjectStore() {
+	// Getting the storage backend from the environment file, the mini-io server unless configured otherwise.
+	switch os.Getenv("OBJECT_STORE") {
+	case object_store.StoreLocal:
+		// Set the local object store, keeping the objects under the configured folder.
+		var err error
+		miniIoClient.Store, err = object_store.NewLocalStore(os.Getenv("OBJECT_STORE_PATH"))
+		if err != nil {
+			log.Fatalf("Error: Problem while creating local object store.       %s", err.Error())
+			os.Exit(1)
+		}
+		miniIoClient.BucketName = os.Getenv("MINIIO_BUCKET_NAME")
+
+		log.Println("Message: Using local object store successfully.")
+	case object_store.StoreMemory:
+		// Set the in-memory object store.
+		miniIoClient.Store = object_store.NewMemoryStore()
+		miniIoClient.BucketName = os.Getenv("MINIIO_BUCKET_NAME")
+
+		log.Println("Message: Using in-memory object store successfully.")
+	default:
+		connectMiniIo()
+	}
+}
+
+// Function to check whether the objects are kept in a store shared by the nodes, rather than in the node itself.
+func IsSharedObjectStore() bool {
+	// Loading the environment variables.
+	storeType := os.Getenv("OBJECT_STORE")
+	return storeType != object_store.StoreLocal && storeType != object_store.StoreMemory
+}
+
+// Function to connect to the mini-io server and set it as the object store.
+func connectMiniIo() {
 	// Getting all the parameters from the environment file.
 	endPoint := os.Getenv("MINIIO_ENDPOINT")
 	accessKeyId := os.Getenv("MINIIO_ACCESS_KEY_ID")
@@ -37,19 +91,17 @@ func init() {
 		os.Exit(1)
 	}
 
-	miniIoClient.Once.Do(func() {
-		// Set new mini-io client.
-		miniIoClient.Client, err = minio.New(endPoint, accessKeyId, secretAccessKey, useSSL)
-		if err != nil {
-			log.Fatalf("Error: Problem while connecting to Mini-Io client.       %s", err.Error())
-			os.Exit(1)
-		}
-
-		// Set the bucket name and location to the structure.
-		miniIoClient.BucketName = bucketName
-		miniIoClient.Location = location
+	// Set new mini-io client.
+	miniIoClient.Client, err = minio.New(endPoint, accessKeyId, secretAccessKey, useSSL)
+	if err != nil {
+		log.Fatalf("Error: Problem while connecting to Mini-Io client.       %s", err.Error())
+		os.Exit(1)
+	}
 
-		log.Println("Message: Connected to Minio-Io client successfully.")
-	})
+	// Set the bucket name and location to the structure.
+	miniIoClient.BucketName = bucketName
+	miniIoClient.Location = location
+	miniIoClient.Store = object_store.NewMinioStore(miniIoClient.Client, bucketName)
 
+	log.Println("Message: Connected to Minio-Io client successfully.")
 }
@@ -1,7 +1,9 @@
 package models
 
 import (
+	file_models "ImageUploadMiniIo/pkg/file_processor/models"
 	chunk_models "ImageUploadMiniIo/pkg/image_chunks/models"
+	object_store_models "ImageUploadMiniIo/pkg/object_store/models"
 	"context"
 	"sync"
 	"time"
@@ -9,6 +11,13 @@ import (
 	minio "github.com/minio/minio-go"
 )
 
+// Interface of the backend indexing the stored objects by the checksum of their content.
+type ChecksumIndex interface {
+	GetObjectName(fileChecksum string) (string, error)
+	SetObjectName(fileChecksum string, objectName string) error
+	RemoveObjectName(fileChecksum string, objectName string) error
+}
+
 type MiniIoClient struct {
 	Ctx   context.Context
 	Cancel     context.CancelFunc
@@ -16,12 +25,22 @@ type MiniIoClient struct {
 	BucketName string
 	Location   string
 	Once       sync.Once
+	Store      object_store_models.ObjectStore
 }
 
 type Metadata struct {
-	SessionId    string                   `json:"session_id"`
-	IPAddress    string                   `json:"ip_address"`
-	UserAgent    string                   `json:"user_agent"`
-	FileDetails  chunk_models.FileDetails `json:"file_details"`
-	CreationTime time.Time                `json:"creation_time"`
+	SessionId        string                       `json:"session_id"`
+	IPAddress        string                       `json:"ip_address"`
+	UserAgent        string                       `json:"user_agent"`
+	FileDetails      chunk_models.FileDetails     `json:"file_details"`
+	CreationTime     time.Time                    `json:"creation_time"`
+	FileChecksum     string                       `json:"file_checksum"`
+	OriginalChecksum string                       `json:"original_checksum,omitempty"`
+	ContentType      string                       `json:"content_type,omitempty"`
+	DuplicateOf      string                       `json:"duplicate_of,omitempty"`
+	MetadataStripped bool                         `json:"metadata_stripped,omitempty"`
+	Renditions       map[string]string            `json:"renditions,omitempty"`
+	Image            *file_models.ImageProperties `json:"image,omitempty"`
+	PerceptualHash   string                       `json:"perceptual_hash,omitempty"`
+	Scan             *file_models.ScanResult      `json:"scan,omitempty"`
 }
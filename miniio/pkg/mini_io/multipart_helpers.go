@@ -0,0 +1,197 @@
+package miniio
+
+import (
+	chunk_models "ImageUploadMiniIo/pkg/image_chunks/models"
+	"ImageUploadMiniIo/pkg/object_store"
+	object_store_models "ImageUploadMiniIo/pkg/object_store/models"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+)
+
+// Minimum size of every part of a multipart upload except the last one.
+const MinPartSize = 5 * 1024 * 1024
+
+// Function to get the multipart capable object store, failing if the configured one is not.
+func getMultipartStore() (object_store_models.MultipartObjectStore, error) {
+	return object_store.GetMultipartStore(GetObjectStore())
+}
+
+// Function to start a multipart upload for a particular session, into which the chunks are streamed as parts.
+// The upload is completed under the staging prefix and only copied to the name of the session once processed.
+// Incomplete multipart uploads of expired sessions should be cleaned up by a lifecycle rule on the bucket.
+func NewSessionMultipartUpload(sessionData *chunk_models.SessionData) (string, error) {
+	multipartStore, err := getMultipartStore()
+	if err != nil {
+		return "", err
+	}
+
+	// Set metadata for the object, the checksum is only known once all the parts have been received.
+	metaDataMap, err := getObjectMetadata(sessionData, nil)
+	if err != nil {
+		return "", err
+	}
+
+	uploadId, err := multipartStore.NewMultipartUpload(GetSessionMultipartObjectName(sessionData.SessionId), getContentType(sessionData.FileDetails.FileType), metaDataMap)
+	if err != nil {
+		return "", err
+	}
+
+	log.Printf("Message: Started multipart upload in Mini-Io server with session id \"%s\"", sessionData.SessionId)
+
+	return uploadId, nil
+}
+
+// Function to upload a chunk as a part of the multipart upload of a particular session.
+// Returns the ETag of the part, which is needed to complete the upload.
+func UploadSessionChunkPart(sessionData *chunk_models.SessionData, chunkNumber int, data io.Reader, size int64) (string, error) {
+	multipartStore, err := getMultipartStore()
+	if err != nil {
+		return "", err
+	}
+
+	return multipartStore.PutObjectPart(GetSessionMultipartObjectName(sessionData.SessionId), sessionData.MultipartUploadId, chunkNumber, data, size)
+}
+
+// Function to complete the multipart upload of a particular session from the parts received.
+// An upload already completed by a finalize job which did not get to the end is left as it is, so that the job can be run again.
+func CompleteSessionMultipartUpload(sessionData *chunk_models.SessionData) error {
+	multipartStore, err := getMultipartStore()
+	if err != nil {
+		return err
+	}
+
+	_, err = GetObjectStore().StatObject(GetSessionMultipartObjectName(sessionData.SessionId))
+	if err == nil {
+		return nil
+	} else if !errors.Is(err, object_store.ErrObjectNotFound) {
+		return err
+	}
+
+	// The parts should be listed in ascending order of their part numbers.
+	completeParts := make([]object_store_models.CompletePart, 0, len(sessionData.MultipartParts))
+	for partNumber, eTag := range sessionData.MultipartParts {
+		completeParts = append(completeParts, object_store_models.CompletePart{PartNumber: partNumber, ETag: eTag})
+	}
+	sort.Slice(completeParts, func(i, j int) bool {
+		return completeParts[i].PartNumber < completeParts[j].PartNumber
+	})
+
+	if len(completeParts) != sessionData.TotalChunks {
+		return fmt.Errorf("received %d parts out of %d", len(completeParts), sessionData.TotalChunks)
+	}
+
+	err = multipartStore.CompleteMultipartUpload(GetSessionMultipartObjectName(sessionData.SessionId), sessionData.MultipartUploadId, completeParts)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Message: Completed multipart upload in Mini-Io server with session id \"%s\"", sessionData.SessionId)
+
+	return nil
+}
+
+// Function to abort the multipart upload of a particular session, removing the parts already uploaded.
+// An upload that has already been completed or aborted is left as it is.
+func AbortSessionMultipartUpload(sessionId string, uploadId string) error {
+	multipartStore, err := getMultipartStore()
+	if err != nil {
+		return err
+	}
+
+	return multipartStore.AbortMultipartUpload(GetSessionMultipartObjectName(sessionId), uploadId)
+}
+
+// Function to open an object already in the bucket for reading.
+func GetObjectReader(objectName string) (io.ReadCloser, error) {
+	return GetObjectStore().GetObject(objectName)
+}
+
+// Function to compute the SHA-256 checksum of an object already in the bucket.
+func GetObjectChecksum(objectName string) (string, error) {
+	object, err := GetObjectStore().GetObject(objectName)
+	if err != nil {
+		return "", err
+	}
+	defer object.Close()
+
+	hasher := sha256.New()
+	if _, err := io.Copy(hasher, object); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(hasher.Sum(nil)), nil
+}
+
+// Function to check whether an object is in the bucket.
+func ObjectExists(objectName string) (bool, error) {
+	_, err := GetObjectStore().StatObject(objectName)
+	if errors.Is(err, object_store.ErrObjectNotFound) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// Function to copy the completed multipart upload of a particular session from the staging prefix to its final name,
+// with the metadata of the processed file, and remove the staged object.
+// The content type is passed along, as the metadata of the source is not carried over by the copy.
+func promoteSessionMultipartObject(sessionId string, objectName string, contentType string, metaDataMap map[string]string) error {
+	stagedObjectName := GetSessionMultipartObjectName(sessionId)
+
+	err := GetObjectStore().CopyObject(stagedObjectName, objectName, contentType, metaDataMap)
+	if err != nil {
+		return err
+	}
+
+	return RemoveSessionMultipartObject(sessionId)
+}
+
+// Function to remove the completed multipart upload of a particular session from the staging prefix, if it exists.
+func RemoveSessionMultipartObject(sessionId string) error {
+	err := GetObjectStore().RemoveObject(GetSessionMultipartObjectName(sessionId))
+	if errors.Is(err, object_store.ErrObjectNotFound) {
+		return nil
+	}
+
+	return err
+}
+
+// Function to upload a local file as an object into the bucket.
+func putObjectFromFile(objectName string, filePath string, contentType string, metaDataMap map[string]string) error {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	fileInfo, err := file.Stat()
+	if err != nil {
+		return err
+	}
+
+	return GetObjectStore().PutObject(objectName, file, fileInfo.Size(), contentType, metaDataMap)
+}
+
+// Function to replace the content of an object already in the bucket with the content of a local file.
+// The metadata of the object is not carried over, it is written again once the file is stored.
+func ReplaceObjectContent(objectName string, filePath string, contentType string) error {
+	return putObjectFromFile(objectName, filePath, contentType, nil)
+}
+
+// Function to get the size in bytes of an object already in the bucket.
+func GetObjectSize(objectName string) (int64, error) {
+	objectInfo, err := GetObjectStore().StatObject(objectName)
+	if err != nil {
+		return 0, err
+	}
+
+	return objectInfo.Size, nil
+}
@@ -0,0 +1,46 @@
+package miniio
+
+import (
+	file_models "ImageUploadMiniIo/pkg/file_processor/models"
+	chunk_models "ImageUploadMiniIo/pkg/image_chunks/models"
+	"log"
+	"os"
+)
+
+// Function to get the prefix the quarantined objects are stored under, which should be kept private in the bucket policy.
+func getQuarantinePrefix() string {
+	// Loading the environment variables.
+	quarantinePrefix := os.Getenv("QUARANTINE_PREFIX")
+	if quarantinePrefix == "" {
+		return "quarantine/"
+	}
+
+	return quarantinePrefix
+}
+
+// Function to store the rejected file of a particular session under the quarantine prefix, along with the report holding its verdict.
+// If the chunks have been streamed into a multipart upload, the staged object is copied and left for the caller to remove.
+// Returns the name of the quarantined object.
+func QuarantineSessionFile(sessionData *chunk_models.SessionData, fileReport *file_models.FileReport) (string, error) {
+	objectName := getQuarantinePrefix() + sessionData.SessionId
+
+	// Set metadata for the object.
+	metaDataMap, err := getObjectMetadata(sessionData, fileReport)
+	if err != nil {
+		return "", err
+	}
+
+	// The content type is not trusted for a rejected file, so it is stored as plain bytes.
+	if sessionData.MultipartUploadId != "" {
+		err = GetObjectStore().CopyObject(GetSessionMultipartObjectName(sessionData.SessionId), objectName, "application/octet-stream", metaDataMap)
+	} else {
+		err = putObjectFromFile(objectName, getPermFilePath(sessionData), "application/octet-stream", metaDataMap)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	log.Printf("Message: Quarantined object in Mini-Io server with session id \"%s\" as \"%s\"", sessionData.SessionId, objectName)
+
+	return objectName, nil
+}
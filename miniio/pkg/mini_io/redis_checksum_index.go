@@ -0,0 +1,58 @@
+package miniio
+
+import (
+	redis_database "ImageUploadMiniIo/pkg/redis"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// Script to remove the object indexed under a checksum, if it is still the one indexed.
+// KEYS: checksum. ARGV: object name.
+var removeObjectNameScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) ~= ARGV[1] then
+	return 0
+end
+return redis.call("DEL", KEYS[1])
+`)
+
+// Checksum index keeping the object name of every checksum in a redis key, so that it is shared by the nodes.
+type RedisChecksumIndex struct{}
+
+// Function to create a checksum index on the redis client.
+func NewRedisChecksumIndex() *RedisChecksumIndex {
+	return &RedisChecksumIndex{}
+}
+
+// Function to get the redis key indexing the object holding the content with a particular checksum.
+func getChecksumKey(fileChecksum string) string {
+	return "file_checksum:" + fileChecksum
+}
+
+// Function to get the object indexed in the redis under a particular checksum, empty if there is none.
+func (index *RedisChecksumIndex) GetObjectName(fileChecksum string) (string, error) {
+	// Get the redis client.
+	redisClient := redis_database.GetRedisClient()
+
+	objectName, err := redisClient.Client.Get(redisClient.Ctx, getChecksumKey(fileChecksum)).Result()
+	if err == redis.Nil {
+		return "", nil
+	}
+
+	return objectName, err
+}
+
+// Function to index an object in the redis under a particular checksum.
+func (index *RedisChecksumIndex) SetObjectName(fileChecksum string, objectName string) error {
+	// Get the redis client.
+	redisClient := redis_database.GetRedisClient()
+
+	return redisClient.Client.Set(redisClient.Ctx, getChecksumKey(fileChecksum), objectName, 0).Err()
+}
+
+// Function to remove an object from the redis under a particular checksum, unless another object has been indexed since.
+func (index *RedisChecksumIndex) RemoveObjectName(fileChecksum string, objectName string) error {
+	// Get the redis client.
+	redisClient := redis_database.GetRedisClient()
+
+	return removeObjectNameScript.Run(redisClient.Ctx, redisClient.Client, []string{getChecksumKey(fileChecksum)}, objectName).Err()
+}
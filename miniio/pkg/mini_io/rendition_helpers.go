@@ -0,0 +1,46 @@
+package miniio
+
+import (
+	file_models "ImageUploadMiniIo/pkg/file_processor/models"
+	"bytes"
+	"fmt"
+	"log"
+	"strconv"
+)
+
+// Function to get the name of the object holding a rendition, stored as a sibling of the original object.
+func getRenditionObjectName(objectName string, rendition *file_models.Rendition) string {
+	return fmt.Sprintf("%s_%dpx.%s", objectName, rendition.Size, rendition.Extension)
+}
+
+// Function to upload the renditions generated for the compiled file, recording the name of every object in the report.
+func uploadRenditions(objectName string, fileReport *file_models.FileReport) error {
+	for _, rendition := range fileReport.Renditions {
+		renditionObjectName := getRenditionObjectName(objectName, rendition)
+		err := GetObjectStore().PutObject(renditionObjectName, bytes.NewReader(rendition.Data), int64(len(rendition.Data)), rendition.ContentType, nil)
+		if err != nil {
+			return err
+		}
+		rendition.ObjectName = renditionObjectName
+	}
+
+	if len(fileReport.Renditions) > 0 {
+		log.Printf("Message: Successfully uploaded %d renditions in Mini-Io server for object \"%s\"", len(fileReport.Renditions), objectName)
+	}
+
+	return nil
+}
+
+// Function to get the names of the rendition objects keyed by their size, as recorded in the object metadata.
+func getRenditionObjectNames(fileReport *file_models.FileReport) map[string]string {
+	if len(fileReport.Renditions) == 0 {
+		return nil
+	}
+
+	renditionObjectNames := make(map[string]string, len(fileReport.Renditions))
+	for _, rendition := range fileReport.Renditions {
+		renditionObjectNames[strconv.Itoa(rendition.Size)] = rendition.ObjectName
+	}
+
+	return renditionObjectNames
+}
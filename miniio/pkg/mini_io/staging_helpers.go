@@ -0,0 +1,151 @@
+package miniio
+
+import (
+	chunk_models "ImageUploadMiniIo/pkg/image_chunks/models"
+	"ImageUploadMiniIo/pkg/object_store"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Function to get the prefix the chunks are staged under in the shared staging mode, which should be kept private in the bucket policy.
+func getStagingPrefix() string {
+	// Loading the environment variables.
+	stagingPrefix := os.Getenv("STAGING_PREFIX")
+	if stagingPrefix == "" {
+		return "staging/"
+	}
+
+	return stagingPrefix
+}
+
+// Function to get the prefix all the staged objects of a particular session are stored under.
+func getStagedSessionPrefix(sessionId string) string {
+	return getStagingPrefix() + sessionId + "/"
+}
+
+// Function to get the name of the object the multipart upload of a particular session is completed into.
+// It is kept under the staging prefix until the file has been processed, so that a rejected file is never exposed
+// under the name of the session.
+func GetSessionMultipartObjectName(sessionId string) string {
+	return getStagedSessionPrefix(sessionId) + "upload"
+}
+
+// Function to get the name of the staged object of a particular chunk.
+func getStagedChunkName(sessionId string, chunkNumber int) string {
+	return fmt.Sprintf("%schunk_%d", getStagedSessionPrefix(sessionId), chunkNumber)
+}
+
+// Function to get the name of the staged object of a particular byte range.
+func getStagedRangeName(sessionId string, byteRange chunk_models.ByteRange) string {
+	return fmt.Sprintf("%srange_%d_%d", getStagedSessionPrefix(sessionId), byteRange.Start, byteRange.End)
+}
+
+// Function to stage a chunk of a particular session in the object store, so that it can be assembled on any node.
+// A chunk re-sent by the client overwrites the one staged before.
+func StageSessionChunk(sessionId string, chunkNumber int, data io.Reader, size int64) error {
+	return GetObjectStore().PutObject(getStagedChunkName(sessionId, chunkNumber), data, size, "application/octet-stream", nil)
+}
+
+// Function to remove a staged chunk of a particular session, if it exists.
+func RemoveStagedChunk(sessionId string, chunkNumber int) error {
+	err := GetObjectStore().RemoveObject(getStagedChunkName(sessionId, chunkNumber))
+	if errors.Is(err, object_store.ErrObjectNotFound) {
+		return nil
+	}
+
+	return err
+}
+
+// Function to open a staged chunk of a particular session for reading.
+func GetStagedChunkReader(sessionId string, chunkNumber int) (io.ReadCloser, error) {
+	return GetObjectStore().GetObject(getStagedChunkName(sessionId, chunkNumber))
+}
+
+// Function to stage a byte range of a particular session in the object store, so that it can be assembled on any node.
+// The staged object is removed again if fewer bytes than the length of the range have been received.
+func StageSessionRange(sessionId string, byteRange chunk_models.ByteRange, data io.Reader) error {
+	objectName := getStagedRangeName(sessionId, byteRange)
+	rangeLength := byteRange.End - byteRange.Start + 1
+
+	err := GetObjectStore().PutObject(objectName, io.LimitReader(data, rangeLength), rangeLength, "application/octet-stream", nil)
+	if err != nil {
+		return err
+	}
+
+	objectInfo, err := GetObjectStore().StatObject(objectName)
+	if err != nil {
+		return err
+	}
+	if objectInfo.Size != rangeLength {
+		GetObjectStore().RemoveObject(objectName)
+		return fmt.Errorf("received %d bytes for a range of %d bytes", objectInfo.Size, rangeLength)
+	}
+
+	return nil
+}
+
+// Function to list the byte ranges staged for a particular session, in ascending order of their start.
+// The ranges may overlap, as the client can re-send a range which has partly been received before.
+func ListStagedRanges(sessionId string) ([]chunk_models.ByteRange, error) {
+	objects, err := GetObjectStore().ListObjects(getStagedSessionPrefix(sessionId) + "range_")
+	if err != nil {
+		return nil, err
+	}
+
+	byteRanges := make([]chunk_models.ByteRange, 0, len(objects))
+	for _, object := range objects {
+		var byteRange chunk_models.ByteRange
+		rangeName := strings.TrimPrefix(object.ObjectName, getStagedSessionPrefix(sessionId))
+		_, err := fmt.Sscanf(rangeName, "range_%d_%d", &byteRange.Start, &byteRange.End)
+		if err != nil {
+			return nil, fmt.Errorf("invalid staged range \"%s\": %w", object.ObjectName, err)
+		}
+		byteRanges = append(byteRanges, byteRange)
+	}
+	sort.Slice(byteRanges, func(i, j int) bool {
+		return byteRanges[i].Start < byteRanges[j].Start
+	})
+
+	return byteRanges, nil
+}
+
+// Function to open a staged byte range of a particular session for reading.
+func GetStagedRangeReader(sessionId string, byteRange chunk_models.ByteRange) (io.ReadCloser, error) {
+	return GetObjectStore().GetObject(getStagedRangeName(sessionId, byteRange))
+}
+
+// Function to count the objects staged for a particular session.
+func CountStagedObjects(sessionId string) (int, error) {
+	objects, err := GetObjectStore().ListObjects(getStagedSessionPrefix(sessionId))
+	if err != nil {
+		return 0, err
+	}
+
+	return len(objects), nil
+}
+
+// Function to remove all the objects staged for a particular session.
+// Returns the number of objects removed.
+func RemoveStagedObjects(sessionId string) (int, error) {
+	objects, err := GetObjectStore().ListObjects(getStagedSessionPrefix(sessionId))
+	if err != nil {
+		return 0, err
+	}
+
+	removed := 0
+	for _, object := range objects {
+		err := GetObjectStore().RemoveObject(object.ObjectName)
+		if errors.Is(err, object_store.ErrObjectNotFound) {
+			continue
+		} else if err != nil {
+			return removed, err
+		}
+		removed++
+	}
+
+	return removed, nil
+}
@@ -0,0 +1,258 @@
+package object_store
+
+import (
+	object_store_models "ImageUploadMiniIo/pkg/object_store/models"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Folder inside the root folder of a local store holding the content type and user metadata of the objects.
+const localMetadataFolder = ".metadata"
+
+// Object store keeping the objects as files under a root folder, meant for running without a mini-io server.
+type LocalStore struct {
+	RootPath string
+}
+
+// Content type and user metadata of an object in a local store, kept in a json file next to the objects.
+type localObjectMetadata struct {
+	ContentType  string            `json:"content_type"`
+	UserMetadata map[string]string `json:"user_metadata,omitempty"`
+}
+
+// Function to create the object store for a root folder, creating the folder if it does not exist.
+func NewLocalStore(rootPath string) (*LocalStore, error) {
+	if rootPath == "" {
+		return nil, fmt.Errorf("root path of the local object store is required")
+	}
+
+	err := os.MkdirAll(rootPath, os.ModePerm)
+	if err != nil {
+		return nil, err
+	}
+
+	return &LocalStore{RootPath: rootPath}, nil
+}
+
+// Function to get the path of the file holding an object and the one holding its metadata.
+// The object name should stay inside the root folder once cleaned.
+func (store *LocalStore) getPaths(objectName string) (string, string, error) {
+	cleanName := path.Clean("/" + objectName)[1:]
+	if cleanName == "" || cleanName != objectName || strings.HasPrefix(cleanName, localMetadataFolder+"/") {
+		return "", "", fmt.Errorf("invalid object name \"%s\"", objectName)
+	}
+
+	objectPath := filepath.Join(store.RootPath, filepath.FromSlash(cleanName))
+	metadataPath := filepath.Join(store.RootPath, localMetadataFolder, filepath.FromSlash(cleanName)+".json")
+
+	return objectPath, metadataPath, nil
+}
+
+// Function to write a file by writing a temporary file next to it and renaming it, so that readers never see a partial file.
+func writeFileAtomically(filePath string, reader io.Reader) error {
+	err := os.MkdirAll(filepath.Dir(filePath), os.ModePerm)
+	if err != nil {
+		return err
+	}
+
+	tempFile, err := os.CreateTemp(filepath.Dir(filePath), "."+filepath.Base(filePath)+"-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tempFile.Name())
+	defer tempFile.Close()
+
+	_, err = io.Copy(tempFile, reader)
+	if err != nil {
+		return err
+	}
+	err = tempFile.Close()
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tempFile.Name(), filePath)
+}
+
+// Function to read the metadata of an object, empty if it has none.
+func readLocalObjectMetadata(metadataPath string) (*localObjectMetadata, error) {
+	var metadata localObjectMetadata
+	jsonData, err := os.ReadFile(metadataPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return &metadata, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	err = json.Unmarshal(jsonData, &metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	return &metadata, nil
+}
+
+// Function to write an object into the root folder along with its metadata.
+func (store *LocalStore) PutObject(objectName string, reader io.Reader, size int64, contentType string, userMetadata map[string]string) error {
+	objectPath, metadataPath, err := store.getPaths(objectName)
+	if err != nil {
+		return err
+	}
+
+	jsonData, err := json.Marshal(localObjectMetadata{ContentType: contentType, UserMetadata: userMetadata})
+	if err != nil {
+		return err
+	}
+
+	err = writeFileAtomically(objectPath, reader)
+	if err != nil {
+		return err
+	}
+
+	return writeFileAtomically(metadataPath, strings.NewReader(string(jsonData)))
+}
+
+// Function to open an object in the root folder for reading.
+func (store *LocalStore) GetObject(objectName string) (io.ReadCloser, error) {
+	objectPath, _, err := store.getPaths(objectName)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(objectPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrObjectNotFound
+	}
+
+	return file, err
+}
+
+// Function to get the information of an object in the root folder.
+func (store *LocalStore) StatObject(objectName string) (*object_store_models.ObjectInfo, error) {
+	objectPath, metadataPath, err := store.getPaths(objectName)
+	if err != nil {
+		return nil, err
+	}
+
+	fileInfo, err := os.Stat(objectPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrObjectNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	metadata, err := readLocalObjectMetadata(metadataPath)
+	if err != nil {
+		return nil, err
+	}
+
+	return &object_store_models.ObjectInfo{
+		ObjectName:   objectName,
+		Size:         fileInfo.Size(),
+		ContentType:  metadata.ContentType,
+		UserMetadata: metadata.UserMetadata,
+		LastModified: fileInfo.ModTime(),
+	}, nil
+}
+
+// Function to copy an object in the root folder, replacing its metadata rather than carrying it over.
+func (store *LocalStore) CopyObject(sourceObjectName string, objectName string, contentType string, userMetadata map[string]string) error {
+	source, err := store.GetObject(sourceObjectName)
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+
+	return store.PutObject(objectName, source, -1, contentType, userMetadata)
+}
+
+// Function to remove an object and its metadata from the root folder.
+func (store *LocalStore) RemoveObject(objectName string) error {
+	objectPath, metadataPath, err := store.getPaths(objectName)
+	if err != nil {
+		return err
+	}
+
+	err = os.Remove(objectPath)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	err = os.Remove(metadataPath)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	return nil
+}
+
+// Function to list the objects in the root folder whose name starts with the prefix.
+func (store *LocalStore) ListObjects(prefix string) ([]object_store_models.ObjectInfo, error) {
+	objects := make([]object_store_models.ObjectInfo, 0)
+	err := filepath.WalkDir(store.RootPath, func(filePath string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		// Skip the metadata folder and the temporary files being written.
+		if entry.IsDir() {
+			if entry.Name() == localMetadataFolder && filepath.Dir(filePath) == filepath.Clean(store.RootPath) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if strings.HasPrefix(entry.Name(), ".") {
+			return nil
+		}
+
+		relativePath, err := filepath.Rel(store.RootPath, filePath)
+		if err != nil {
+			return err
+		}
+		objectName := filepath.ToSlash(relativePath)
+		if !strings.HasPrefix(objectName, prefix) {
+			return nil
+		}
+
+		objectInfo, err := store.StatObject(objectName)
+		if err != nil {
+			return err
+		}
+		objects = append(objects, *objectInfo)
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(objects, func(i, j int) bool {
+		return objects[i].ObjectName < objects[j].ObjectName
+	})
+
+	return objects, nil
+}
+
+// Function to get a url of an object in the root folder.
+// The files are only reachable from the machine running the service, so the url is a plain file url which never expires.
+func (store *LocalStore) PresignGetObject(objectName string, expiry time.Duration) (string, error) {
+	objectPath, _, err := store.getPaths(objectName)
+	if err != nil {
+		return "", err
+	}
+
+	absolutePath, err := filepath.Abs(objectPath)
+	if err != nil {
+		return "", err
+	}
+
+	return "file://" + filepath.ToSlash(absolutePath), nil
+}
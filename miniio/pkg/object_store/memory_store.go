@@ -0,0 +1,143 @@
+package object_store
+
+import (
+	object_store_models "ImageUploadMiniIo/pkg/object_store/models"
+	"bytes"
+	"io"
+	"maps"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Object store keeping the objects in memory, meant for tests and short lived runs as nothing survives a restart.
+type MemoryStore struct {
+	mutex   sync.RWMutex
+	objects map[string]*memoryObject
+}
+
+// Object held in a memory store.
+type memoryObject struct {
+	data         []byte
+	contentType  string
+	userMetadata map[string]string
+	lastModified time.Time
+}
+
+// Function to create an empty object store in memory.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{objects: make(map[string]*memoryObject)}
+}
+
+// Function to get the information of an object held in memory.
+func (object *memoryObject) getObjectInfo(objectName string) *object_store_models.ObjectInfo {
+	return &object_store_models.ObjectInfo{
+		ObjectName:   objectName,
+		Size:         int64(len(object.data)),
+		ContentType:  object.contentType,
+		UserMetadata: maps.Clone(object.userMetadata),
+		LastModified: object.lastModified,
+	}
+}
+
+// Function to read an object into memory.
+func (store *MemoryStore) PutObject(objectName string, reader io.Reader, size int64, contentType string, userMetadata map[string]string) error {
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return err
+	}
+
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	store.objects[objectName] = &memoryObject{
+		data:         data,
+		contentType:  contentType,
+		userMetadata: maps.Clone(userMetadata),
+		lastModified: time.Now(),
+	}
+
+	return nil
+}
+
+// Function to open an object held in memory for reading.
+// The data of an object is never modified once written, so it is read without copying.
+func (store *MemoryStore) GetObject(objectName string) (io.ReadCloser, error) {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	object, ok := store.objects[objectName]
+	if !ok {
+		return nil, ErrObjectNotFound
+	}
+
+	return io.NopCloser(bytes.NewReader(object.data)), nil
+}
+
+// Function to get the information of an object held in memory.
+func (store *MemoryStore) StatObject(objectName string) (*object_store_models.ObjectInfo, error) {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	object, ok := store.objects[objectName]
+	if !ok {
+		return nil, ErrObjectNotFound
+	}
+
+	return object.getObjectInfo(objectName), nil
+}
+
+// Function to copy an object held in memory, replacing its metadata rather than carrying it over.
+func (store *MemoryStore) CopyObject(sourceObjectName string, objectName string, contentType string, userMetadata map[string]string) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	source, ok := store.objects[sourceObjectName]
+	if !ok {
+		return ErrObjectNotFound
+	}
+
+	store.objects[objectName] = &memoryObject{
+		data:         source.data,
+		contentType:  contentType,
+		userMetadata: maps.Clone(userMetadata),
+		lastModified: time.Now(),
+	}
+
+	return nil
+}
+
+// Function to remove an object from memory.
+func (store *MemoryStore) RemoveObject(objectName string) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	delete(store.objects, objectName)
+
+	return nil
+}
+
+// Function to list the objects held in memory whose name starts with the prefix.
+func (store *MemoryStore) ListObjects(prefix string) ([]object_store_models.ObjectInfo, error) {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	objects := make([]object_store_models.ObjectInfo, 0)
+	for objectName, object := range store.objects {
+		if strings.HasPrefix(objectName, prefix) {
+			objects = append(objects, *object.getObjectInfo(objectName))
+		}
+	}
+
+	sort.Slice(objects, func(i, j int) bool {
+		return objects[i].ObjectName < objects[j].ObjectName
+	})
+
+	return objects, nil
+}
+
+// Function to get a url of an object held in memory, which is not possible as nothing outside the service can reach it.
+func (store *MemoryStore) PresignGetObject(objectName string, expiry time.Duration) (string, error) {
+	return "", ErrNotSupported
+}
@@ -0,0 +1,177 @@
+package object_store
+
+import (
+	object_store_models "ImageUploadMiniIo/pkg/object_store/models"
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/minio/minio-go"
+)
+
+// Object store keeping the objects in a bucket of a mini-io or any other s3 compatible server.
+type MinioStore struct {
+	Client     *minio.Client
+	BucketName string
+}
+
+// Function to create the object store for a bucket of a mini-io server.
+func NewMinioStore(client *minio.Client, bucketName string) *MinioStore {
+	return &MinioStore{Client: client, BucketName: bucketName}
+}
+
+// Function to convert an error of the mini-io client, mapping the missing objects to ErrObjectNotFound.
+func convertMinioError(err error) error {
+	if err != nil && minio.ToErrorResponse(err).Code == "NoSuchKey" {
+		return ErrObjectNotFound
+	}
+
+	return err
+}
+
+// Function to get the user metadata out of the headers of an object.
+func getUserMetadata(headers http.Header) map[string]string {
+	userMetadata := make(map[string]string)
+	for key, values := range headers {
+		if name, ok := strings.CutPrefix(key, "X-Amz-Meta-"); ok && len(values) > 0 {
+			userMetadata[name] = values[0]
+		}
+	}
+
+	return userMetadata
+}
+
+// Function to upload an object into the bucket.
+func (store *MinioStore) PutObject(objectName string, reader io.Reader, size int64, contentType string, userMetadata map[string]string) error {
+	_, err := store.Client.PutObjectWithContext(context.Background(), store.BucketName, objectName, reader, size, minio.PutObjectOptions{
+		ContentType:  contentType,
+		UserMetadata: userMetadata,
+	})
+
+	return err
+}
+
+// Function to open an object in the bucket for reading.
+func (store *MinioStore) GetObject(objectName string) (io.ReadCloser, error) {
+	// The object is only fetched on the first read, so it is checked for beforehand.
+	_, err := store.StatObject(objectName)
+	if err != nil {
+		return nil, err
+	}
+
+	return store.Client.GetObject(store.BucketName, objectName, minio.GetObjectOptions{})
+}
+
+// Function to get the information of an object in the bucket.
+func (store *MinioStore) StatObject(objectName string) (*object_store_models.ObjectInfo, error) {
+	objectInfo, err := store.Client.StatObject(store.BucketName, objectName, minio.StatObjectOptions{})
+	if err != nil {
+		return nil, convertMinioError(err)
+	}
+
+	return &object_store_models.ObjectInfo{
+		ObjectName:   objectInfo.Key,
+		Size:         objectInfo.Size,
+		ContentType:  objectInfo.ContentType,
+		UserMetadata: getUserMetadata(objectInfo.Metadata),
+		LastModified: objectInfo.LastModified,
+	}, nil
+}
+
+// Function to copy an object in the bucket.
+// The copy is done on the server, and replaces the metadata of the source rather than carrying it over.
+// Copying an object onto itself replaces its metadata in place.
+func (store *MinioStore) CopyObject(sourceObjectName string, objectName string, contentType string, userMetadata map[string]string) error {
+	userMeta := map[string]string{"Content-Type": contentType}
+	for key, value := range userMetadata {
+		userMeta[key] = value
+	}
+
+	destination, err := minio.NewDestinationInfo(store.BucketName, objectName, nil, userMeta)
+	if err != nil {
+		return err
+	}
+	source := minio.NewSourceInfo(store.BucketName, sourceObjectName, nil)
+
+	return convertMinioError(store.Client.ComposeObject(destination, []minio.SourceInfo{source}))
+}
+
+// Function to remove an object from the bucket.
+func (store *MinioStore) RemoveObject(objectName string) error {
+	return store.Client.RemoveObject(store.BucketName, objectName)
+}
+
+// Function to list the objects in the bucket whose name starts with the prefix.
+func (store *MinioStore) ListObjects(prefix string) ([]object_store_models.ObjectInfo, error) {
+	doneCh := make(chan struct{})
+	defer close(doneCh)
+
+	objects := make([]object_store_models.ObjectInfo, 0)
+	for objectInfo := range store.Client.ListObjectsV2(store.BucketName, prefix, true, doneCh) {
+		if objectInfo.Err != nil {
+			return nil, objectInfo.Err
+		}
+		objects = append(objects, object_store_models.ObjectInfo{
+			ObjectName:   objectInfo.Key,
+			Size:         objectInfo.Size,
+			ContentType:  objectInfo.ContentType,
+			LastModified: objectInfo.LastModified,
+		})
+	}
+
+	return objects, nil
+}
+
+// Function to get a url through which an object can be downloaded without credentials until it expires.
+func (store *MinioStore) PresignGetObject(objectName string, expiry time.Duration) (string, error) {
+	presignedUrl, err := store.Client.PresignedGetObject(store.BucketName, objectName, expiry, url.Values{})
+	if err != nil {
+		return "", err
+	}
+
+	return presignedUrl.String(), nil
+}
+
+// Function to start a multipart upload, into which the parts of an object are uploaded.
+func (store *MinioStore) NewMultipartUpload(objectName string, contentType string, userMetadata map[string]string) (string, error) {
+	return minio.Core{Client: store.Client}.NewMultipartUpload(store.BucketName, objectName, minio.PutObjectOptions{
+		ContentType:  contentType,
+		UserMetadata: userMetadata,
+	})
+}
+
+// Function to upload a part of a multipart upload, returning the ETag of the part needed to complete the upload.
+func (store *MinioStore) PutObjectPart(objectName string, uploadId string, partNumber int, reader io.Reader, size int64) (string, error) {
+	objectPart, err := minio.Core{Client: store.Client}.PutObjectPart(store.BucketName, objectName, uploadId, partNumber, reader, size, "", "", nil)
+	if err != nil {
+		return "", err
+	}
+
+	return objectPart.ETag, nil
+}
+
+// Function to complete a multipart upload from its parts, listed in ascending order of their part numbers.
+func (store *MinioStore) CompleteMultipartUpload(objectName string, uploadId string, parts []object_store_models.CompletePart) error {
+	completeParts := make([]minio.CompletePart, 0, len(parts))
+	for _, part := range parts {
+		completeParts = append(completeParts, minio.CompletePart{PartNumber: part.PartNumber, ETag: part.ETag})
+	}
+
+	_, err := minio.Core{Client: store.Client}.CompleteMultipartUpload(store.BucketName, objectName, uploadId, completeParts)
+
+	return err
+}
+
+// Function to abort a multipart upload, removing the parts already uploaded.
+// An upload that has already been completed or aborted is left as it is.
+func (store *MinioStore) AbortMultipartUpload(objectName string, uploadId string) error {
+	err := minio.Core{Client: store.Client}.AbortMultipartUpload(store.BucketName, objectName, uploadId)
+	if err != nil && minio.ToErrorResponse(err).Code != "NoSuchUpload" {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,39 @@
+package models
+
+import (
+	"io"
+	"time"
+)
+
+// Interface of the storage backend the objects are kept in.
+type ObjectStore interface {
+	PutObject(objectName string, reader io.Reader, size int64, contentType string, userMetadata map[string]string) error
+	GetObject(objectName string) (io.ReadCloser, error)
+	StatObject(objectName string) (*ObjectInfo, error)
+	CopyObject(sourceObjectName string, objectName string, contentType string, userMetadata map[string]string) error
+	RemoveObject(objectName string) error
+	ListObjects(prefix string) ([]ObjectInfo, error)
+	PresignGetObject(objectName string, expiry time.Duration) (string, error)
+}
+
+// Interface of the storage backends which can also assemble an object from parts uploaded separately.
+type MultipartObjectStore interface {
+	ObjectStore
+	NewMultipartUpload(objectName string, contentType string, userMetadata map[string]string) (string, error)
+	PutObjectPart(objectName string, uploadId string, partNumber int, reader io.Reader, size int64) (string, error)
+	CompleteMultipartUpload(objectName string, uploadId string, parts []CompletePart) error
+	AbortMultipartUpload(objectName string, uploadId string) error
+}
+
+type ObjectInfo struct {
+	ObjectName   string            `json:"object_name"`
+	Size         int64             `json:"size"`
+	ContentType  string            `json:"content_type"`
+	UserMetadata map[string]string `json:"user_metadata,omitempty"`
+	LastModified time.Time         `json:"last_modified"`
+}
+
+type CompletePart struct {
+	PartNumber int    `json:"part_number"`
+	ETag       string `json:"etag"`
+}
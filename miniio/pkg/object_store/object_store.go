@@ -0,0 +1,30 @@
+package object_store
+
+import (
+	object_store_models "ImageUploadMiniIo/pkg/object_store/models"
+	"errors"
+	"fmt"
+)
+
+// Names of the storage backends selectable by configuration.
+const (
+	StoreMinio  = "minio"
+	StoreLocal  = "local"
+	StoreMemory = "memory"
+)
+
+// Error returned when an object is not in the store.
+var ErrObjectNotFound = errors.New("object not found")
+
+// Error returned when the store does not support an operation, such as presigning the objects held in memory.
+var ErrNotSupported = errors.New("operation not supported by the object store")
+
+// Function to get the multipart capable store behind an object store.
+func GetMultipartStore(objectStore object_store_models.ObjectStore) (object_store_models.MultipartObjectStore, error) {
+	multipartStore, ok := objectStore.(object_store_models.MultipartObjectStore)
+	if !ok {
+		return nil, fmt.Errorf("%w: multipart uploads", ErrNotSupported)
+	}
+
+	return multipartStore, nil
+}
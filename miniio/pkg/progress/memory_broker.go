@@ -0,0 +1,70 @@
+package progress
+
+import (
+	progress_models "ImageUploadMiniIo/pkg/progress/models"
+	"context"
+	"errors"
+	"sync"
+)
+
+// Number of events buffered for a subscriber of the memory broker, beyond which the events are dropped for it.
+const subscriberBufferSize = 64
+
+// Error returned when an event is dropped for a subscriber which does not keep up with the events.
+var ErrSubscriberBehind = errors.New("event dropped for a subscriber falling behind")
+
+// Broker passing the progress events to the subscribers in the same process, meant for single node deployments and tests.
+type MemoryBroker struct {
+	mutex       sync.Mutex
+	subscribers map[string]map[chan progress_models.Event]struct{}
+}
+
+// Function to create a broker in memory without any subscribers.
+func NewMemoryBroker() *MemoryBroker {
+	return &MemoryBroker{subscribers: make(map[string]map[chan progress_models.Event]struct{})}
+}
+
+// Function to pass a progress event to the subscribers of its session, without waiting for any of them.
+func (broker *MemoryBroker) Publish(event *progress_models.Event) error {
+	broker.mutex.Lock()
+	defer broker.mutex.Unlock()
+
+	var err error
+	for events := range broker.subscribers[event.SessionId] {
+		select {
+		case events <- *event:
+		default:
+			err = ErrSubscriberBehind
+		}
+	}
+
+	return err
+}
+
+// Function to subscribe to the progress events of a particular session in memory, until the context is done.
+func (broker *MemoryBroker) Subscribe(ctx context.Context, sessionId string) (<-chan progress_models.Event, error) {
+	events := make(chan progress_models.Event, subscriberBufferSize)
+
+	broker.mutex.Lock()
+	if broker.subscribers[sessionId] == nil {
+		broker.subscribers[sessionId] = make(map[chan progress_models.Event]struct{})
+	}
+	broker.subscribers[sessionId][events] = struct{}{}
+	broker.mutex.Unlock()
+
+	// Remove the subscriber once the context is done, closing its channel under the mutex so that nothing is sent on it afterwards.
+	go func() {
+		<-ctx.Done()
+
+		broker.mutex.Lock()
+		defer broker.mutex.Unlock()
+
+		delete(broker.subscribers[sessionId], events)
+		if len(broker.subscribers[sessionId]) == 0 {
+			delete(broker.subscribers, sessionId)
+		}
+		close(events)
+	}()
+
+	return events, nil
+}
@@ -0,0 +1,31 @@
+package models
+
+import (
+	"context"
+	"time"
+)
+
+// Types of the progress events of an upload session.
+const (
+	EventChunkReceived  = "chunk-received"
+	EventChunkFailed    = "chunk-failed"
+	EventAssembling     = "assembling"
+	EventProcessing     = "processing"
+	EventStoring        = "storing"
+	EventFinalizeFailed = "finalize-failed"
+	EventCompleted      = "completed"
+	EventFailed         = "failed"
+)
+
+// Interface of the broker the progress events are published through.
+type Broker interface {
+	Publish(event *Event) error
+	Subscribe(ctx context.Context, sessionId string) (<-chan Event, error)
+}
+
+type Event struct {
+	EventType string         `json:"event_type"`
+	SessionId string         `json:"session_id"`
+	Timestamp time.Time      `json:"timestamp"`
+	Data      map[string]any `json:"data,omitempty"`
+}
@@ -0,0 +1,48 @@
+package progress
+
+import (
+	progress_models "ImageUploadMiniIo/pkg/progress/models"
+	redis_database "ImageUploadMiniIo/pkg/redis"
+	"context"
+	"log"
+	"time"
+)
+
+// Declaring the broker the progress events are published through.
+var broker progress_models.Broker
+
+// Init() function to select the broker. Publishing through the redis when configured lets a client follow the
+// session, whichever node is handling its requests.
+func init() {
+	if redis_database.IsConfigured() {
+		broker = NewRedisBroker()
+	} else {
+		broker = NewMemoryBroker()
+	}
+}
+
+// Function to check whether an event ends the progress of a session.
+func IsFinal(eventType string) bool {
+	return eventType == progress_models.EventCompleted || eventType == progress_models.EventFailed
+}
+
+// Function to publish a progress event of a particular session.
+// Publishing is best effort, a failure is only logged so that it never fails the upload itself.
+func Publish(eventType string, sessionId string, data map[string]any) {
+	var event progress_models.Event
+	event.EventType = eventType
+	event.SessionId = sessionId
+	event.Timestamp = time.Now()
+	event.Data = data
+
+	err := broker.Publish(&event)
+	if err != nil {
+		log.Printf("Error: Could not publish \"%s\" event of session id \"%s\": %s", eventType, sessionId, err.Error())
+	}
+}
+
+// Function to subscribe to the progress events of a particular session, until the context is done.
+// The returned channel is closed once the subscription ends.
+func Subscribe(ctx context.Context, sessionId string) (<-chan progress_models.Event, error) {
+	return broker.Subscribe(ctx, sessionId)
+}
@@ -0,0 +1,82 @@
+package progress
+
+import (
+	progress_models "ImageUploadMiniIo/pkg/progress/models"
+	redis_database "ImageUploadMiniIo/pkg/redis"
+	"context"
+	"encoding/json"
+	"log"
+)
+
+// Broker publishing the progress events through the redis pub/sub, so that they reach the subscribers on every node.
+type RedisBroker struct{}
+
+// Function to create a broker on the redis client.
+func NewRedisBroker() *RedisBroker {
+	return &RedisBroker{}
+}
+
+// Function to get the redis channel the progress events of a particular session are published on.
+func getChannel(sessionId string) string {
+	return "upload_events:" + sessionId
+}
+
+// Function to publish a progress event on the redis channel of its session.
+func (broker *RedisBroker) Publish(event *progress_models.Event) error {
+	// Get the redis client.
+	redisClient := redis_database.GetRedisClient()
+
+	jsonData, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	return redisClient.Client.Publish(redisClient.Ctx, getChannel(event.SessionId), jsonData).Err()
+}
+
+// Function to subscribe to the redis channel of a particular session, until the context is done.
+func (broker *RedisBroker) Subscribe(ctx context.Context, sessionId string) (<-chan progress_models.Event, error) {
+	// Get the redis client.
+	redisClient := redis_database.GetRedisClient()
+
+	pubSub := redisClient.Client.Subscribe(ctx, getChannel(sessionId))
+
+	// Wait for the confirmation of the subscription, so that no event published afterwards is missed.
+	_, err := pubSub.Receive(ctx)
+	if err != nil {
+		pubSub.Close()
+		return nil, err
+	}
+
+	events := make(chan progress_models.Event)
+	go func() {
+		defer close(events)
+		defer pubSub.Close()
+
+		messages := pubSub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case message, ok := <-messages:
+				if !ok {
+					return
+				}
+
+				var event progress_models.Event
+				if err := json.Unmarshal([]byte(message.Payload), &event); err != nil {
+					log.Printf("Error: Could not deserialise event of session id \"%s\": %s", sessionId, err.Error())
+					continue
+				}
+
+				select {
+				case events <- event:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return events, nil
+}
@@ -2,8 +2,4 @@ package models
 
 func (redisClient *RedisClient) ShutDown() {
 	redisClient.Cancel()
-
-	redisClient.Wg.Wait()
-
-	redisClient.ExpireChannel.Close()
 }
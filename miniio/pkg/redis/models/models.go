@@ -8,10 +8,8 @@ import (
 )
 
 type RedisClient struct {
-	Client        *redis.Client
-	Ctx           context.Context
-	Once          sync.Once
-	Cancel        context.CancelFunc
-	ExpireChannel *redis.PubSub
-	Wg            sync.WaitGroup
+	Client *redis.Client
+	Ctx    context.Context
+	Once   sync.Once
+	Cancel context.CancelFunc
 }
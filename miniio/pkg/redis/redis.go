@@ -3,6 +3,8 @@ package redis
 import (
 	redis_models "ImageUploadMiniIo/pkg/redis/models"
 	"context"
+	"errors"
+	"io/fs"
 	"log"
 	"os"
 	"time"
@@ -14,45 +16,48 @@ import (
 // Declaring a RedisClient type declared inside the models.go file in model package.
 var redisClient redis_models.RedisClient
 
-// Init() function to initiate the redis client.
+// Init() function to load the redis environment variables.
+// The client itself is only created once a backend configured to use the redis asks for it.
 func init() {
-	// Loading the redis address and password environment variables.
+	// Loading the redis address and password environment variables, which may also be set without an environment file.
 	err := godotenv.Load(".env")
-	if err != nil {
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
 		log.Fatalf("Error: Problem while loading environment variables.")
 		os.Exit(1)
 	}
+}
 
+// Function to check whether a redis server is configured, in which case the state shared across the nodes is kept in it.
+// Without it, every backend falls back to its in-process implementation, meant for single node deployments and tests.
+func IsConfigured() bool {
+	// Loading the environment variables.
+	return os.Getenv("REDIS_ADDRESS") != ""
+}
+
+// Function to connect to the redis server, called once by the first backend asking for the client.
+func connectRedis() {
+	// Loading the redis address and password environment variables.
 	address := os.Getenv("REDIS_ADDRESS")
 	password := os.Getenv("REDIS_PASSOWRD")
 
-	// Once Do is used to make sure only one time this code within is executed in case of multi-threaded excution.
-	redisClient.Once.Do(func() {
-		// Set redis context.
-		redisClient.Ctx, redisClient.Cancel = context.WithTimeout(context.Background(), 20*time.Second)
-
-		// Set new redis client.
-		redisClient.Client = redis.NewClient(&redis.Options{
-			Addr:     address,
-			Password: password,
-			DB:       0,
-		})
-
-		// Subscribe to keyspace notifications for expired keys.
-		redisClient.ExpireChannel = redisClient.Client.PSubscribe(redisClient.Ctx, "__keyevent@0__:expired")
-		log.Println("Message: Subscribed to keyspace notifications.")
-
-		// Starting a go routine to handle the incoming messages, which willl be used to delete the expired session folders.
-		redisClient.Wg.Add(1)
-		go handleMessages()
-
-		// Ping the redis client.
-		_, err := redisClient.Client.Ping(redisClient.Ctx).Result()
-		if err != nil {
-			log.Fatalf("Error: Problem while pinging the redis client.")
-			os.Exit(1)
-		}
-
-		log.Println("Message: Pinging redis client successful.")
+	// Set redis context, which lives until the client is shut down.
+	redisClient.Ctx, redisClient.Cancel = context.WithCancel(context.Background())
+
+	// Set new redis client.
+	redisClient.Client = redis.NewClient(&redis.Options{
+		Addr:     address,
+		Password: password,
+		DB:       0,
 	})
+
+	// Ping the redis client.
+	pingCtx, pingCancel := context.WithTimeout(redisClient.Ctx, 20*time.Second)
+	defer pingCancel()
+	_, err := redisClient.Client.Ping(pingCtx).Result()
+	if err != nil {
+		log.Fatalf("Error: Problem while pinging the redis client.")
+		os.Exit(1)
+	}
+
+	log.Println("Message: Pinging redis client successful.")
 }
@@ -0,0 +1,41 @@
+package session_folders
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// Function to get the temporary folder the chunks of a particular session are saved in, the folder is hidden.
+func GetTempFolderPath(sessionId string) string {
+	// Loading the environment variables.
+	return filepath.Join(os.Getenv("FOLDER_TEMP_PATH"), "."+sessionId)
+}
+
+// Function to get the permanent folder the whole file of a particular session is compiled in.
+func GetPermFolderPath(sessionId string) string {
+	// Loading the environment variables.
+	return filepath.Join(os.Getenv("FOLDER_PERM_PATH"), sessionId)
+}
+
+// Function to delete a folder and its contents, if it exists.
+func deleteFolder(folderPath string) error {
+	// Check if the folder exists.
+	if _, err := os.Stat(folderPath); os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	// Remove the folder and its contents.
+	return os.RemoveAll(folderPath)
+}
+
+// Function to delete the temporary hidden folder containing the chunks for a particular session.
+func DeleteTempFolder(sessionId string) error {
+	return deleteFolder(GetTempFolderPath(sessionId))
+}
+
+// Function to delete the permanent folder containing the whole compiled file for a particular session.
+func DeletePermFolder(sessionId string) error {
+	return deleteFolder(GetPermFolderPath(sessionId))
+}
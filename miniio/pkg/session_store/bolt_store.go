@@ -0,0 +1,266 @@
+package session_store
+
+import (
+	chunk_models "ImageUploadMiniIo/pkg/image_chunks/models"
+	"encoding/json"
+	"sync"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// Bucket of the bolt database holding the sessions.
+var boltSessionsBucket = []byte("sessions")
+
+// Session store keeping the sessions in an embedded bolt database file, meant for single node deployments.
+// The sessions survive a restart of the service, and every update runs in a transaction of its own.
+type BoltStore struct {
+	db        *bolt.DB
+	onExpired func(sessionId string)
+	done      chan struct{}
+	wg        sync.WaitGroup
+}
+
+// Session held in a bolt store, along with the time it expires at.
+type boltSession struct {
+	ExpiryTime  time.Time       `json:"expiry_time"`
+	SessionData json.RawMessage `json:"session_data"`
+}
+
+// Function to open the session store on a bolt database file, calling onExpired for every session once it expires.
+func NewBoltStore(path string, onExpired func(sessionId string)) (*BoltStore, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(boltSessionsBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	var store BoltStore
+	store.db = db
+	store.onExpired = onExpired
+	store.done = make(chan struct{})
+
+	// Starting a go routine to remove the expired sessions.
+	store.wg.Add(1)
+	go store.sweepExpired()
+
+	return &store, nil
+}
+
+// Function to read a session which has not expired from a transaction, nil if there is none.
+func getBoltSession(tx *bolt.Tx, sessionId string) (*boltSession, error) {
+	value := tx.Bucket(boltSessionsBucket).Get([]byte(sessionId))
+	if value == nil {
+		return nil, nil
+	}
+
+	var session boltSession
+	err := json.Unmarshal(value, &session)
+	if err != nil {
+		return nil, err
+	}
+	if !time.Now().Before(session.ExpiryTime) {
+		return nil, nil
+	}
+
+	return &session, nil
+}
+
+// Function to write a session in a transaction.
+func putBoltSession(tx *bolt.Tx, sessionId string, session *boltSession) error {
+	value, err := json.Marshal(session)
+	if err != nil {
+		return err
+	}
+
+	return tx.Bucket(boltSessionsBucket).Put([]byte(sessionId), value)
+}
+
+// Function to remove the expired sessions at every interval until the store is closed.
+func (store *BoltStore) sweepExpired() {
+	defer store.wg.Done()
+
+	ticker := time.NewTicker(expirySweepInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-store.done:
+			return
+		case <-ticker.C:
+		}
+
+		// Remove the expired sessions in a transaction, and notify about them once it is committed.
+		var expiredIds []string
+		err := store.db.Update(func(tx *bolt.Tx) error {
+			expiredIds = nil
+			cursor := tx.Bucket(boltSessionsBucket).Cursor()
+			for key, value := cursor.First(); key != nil; key, value = cursor.Next() {
+				var session boltSession
+				if err := json.Unmarshal(value, &session); err != nil || !time.Now().Before(session.ExpiryTime) {
+					if err := cursor.Delete(); err != nil {
+						return err
+					}
+					expiredIds = append(expiredIds, string(key))
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			continue
+		}
+
+		for _, sessionId := range expiredIds {
+			store.onExpired(sessionId)
+		}
+	}
+}
+
+// Function to write the session data into the database against its session id.
+func (store *BoltStore) Create(sessionData *chunk_models.SessionData, ttl time.Duration) error {
+	jsonData, err := json.Marshal(sessionData)
+	if err != nil {
+		return err
+	}
+
+	return store.db.Update(func(tx *bolt.Tx) error {
+		return putBoltSession(tx, sessionData.SessionId, &boltSession{ExpiryTime: time.Now().Add(ttl), SessionData: jsonData})
+	})
+}
+
+// Function to get the session data and its remaining time to live for a particular session id.
+func (store *BoltStore) Get(sessionId string) (*chunk_models.SessionData, time.Duration, error) {
+	var sessionData *chunk_models.SessionData
+	var ttl time.Duration
+	err := store.db.View(func(tx *bolt.Tx) error {
+		session, err := getBoltSession(tx, sessionId)
+		if err != nil {
+			return err
+		}
+		if session == nil {
+			return ErrSessionNotFound
+		}
+
+		sessionData, err = decodeSessionData(session.SessionData)
+		ttl = time.Until(session.ExpiryTime)
+		return err
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return sessionData, ttl, nil
+}
+
+// Function to check whether the session exists in the database and has not expired.
+func (store *BoltStore) Exists(sessionId string) (bool, error) {
+	var exists bool
+	err := store.db.View(func(tx *bolt.Tx) error {
+		session, err := getBoltSession(tx, sessionId)
+		exists = session != nil
+		return err
+	})
+
+	return exists, err
+}
+
+// Function to update the session data for a particular session in a transaction, keeping its expiry time.
+// Returns the updated session data.
+func (store *BoltStore) Update(sessionId string, update func(sessionData *chunk_models.SessionData)) (*chunk_models.SessionData, error) {
+	var sessionData *chunk_models.SessionData
+	err := store.db.Update(func(tx *bolt.Tx) error {
+		session, err := getBoltSession(tx, sessionId)
+		if err != nil {
+			return err
+		}
+		if session == nil {
+			return ErrSessionNotFound
+		}
+
+		sessionData, err = decodeSessionData(session.SessionData)
+		if err != nil {
+			return err
+		}
+
+		update(sessionData)
+
+		session.SessionData, err = json.Marshal(sessionData)
+		if err != nil {
+			return err
+		}
+		return putBoltSession(tx, sessionId, session)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return sessionData, nil
+}
+
+// Function to add a chunk to the received chunks of a particular session.
+func (store *BoltStore) MarkReceived(sessionId string, chunkNumber int) (*chunk_models.SessionData, error) {
+	return store.Update(sessionId, func(sessionData *chunk_models.SessionData) {
+		markReceived(sessionData, chunkNumber)
+	})
+}
+
+// Function to add a chunk to the failed chunks of a particular session.
+// Returns the number of times the chunk has failed so far.
+func (store *BoltStore) MarkFailed(sessionId string, chunkNumber int) (int, error) {
+	var failCount int
+	_, err := store.Update(sessionId, func(sessionData *chunk_models.SessionData) {
+		failCount = markFailed(sessionData, chunkNumber)
+	})
+
+	return failCount, err
+}
+
+// Function to remove a chunk from the failed chunks of a particular session.
+func (store *BoltStore) ClearFailed(sessionId string, chunkNumber int) error {
+	_, err := store.Update(sessionId, func(sessionData *chunk_models.SessionData) {
+		clearFailed(sessionData, chunkNumber)
+	})
+
+	return err
+}
+
+// Function to delete a session from the database, if it exists.
+// Returns the deleted session data, nil if there was none.
+func (store *BoltStore) Delete(sessionId string) (*chunk_models.SessionData, error) {
+	var sessionData *chunk_models.SessionData
+	err := store.db.Update(func(tx *bolt.Tx) error {
+		session, err := getBoltSession(tx, sessionId)
+		if err != nil {
+			return err
+		}
+
+		err = tx.Bucket(boltSessionsBucket).Delete([]byte(sessionId))
+		if err != nil || session == nil {
+			return err
+		}
+
+		sessionData, err = decodeSessionData(session.SessionData)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return sessionData, nil
+}
+
+// Function to stop removing the expired sessions, waiting for the go routine doing it, and close the database.
+func (store *BoltStore) Close() error {
+	close(store.done)
+	store.wg.Wait()
+
+	return store.db.Close()
+}
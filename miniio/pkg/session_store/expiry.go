@@ -0,0 +1,61 @@
+package session_store
+
+import (
+	"ImageUploadMiniIo/pkg/lease"
+	"ImageUploadMiniIo/pkg/session_folders"
+	"ImageUploadMiniIo/pkg/webhook"
+	webhook_models "ImageUploadMiniIo/pkg/webhook/models"
+	"errors"
+	"log"
+	"sync"
+	"time"
+)
+
+// Time the lease taken by the node handling an expired session is held for. It is left to expire rather than released,
+// so that the nodes notified of the expiry later on still find it held.
+const expiryLeaseTTL = 10 * time.Minute
+
+// Declaring the handlers called for every expired session, along with the mutex guarding them.
+var expiryHandlers []func(sessionId string)
+var expiryHandlersMutex sync.RWMutex
+
+// Function to add a handler called for every expired session, to clean up what the session holds outside of the local folders.
+func AddExpiryHandler(handler func(sessionId string)) {
+	expiryHandlersMutex.Lock()
+	defer expiryHandlersMutex.Unlock()
+
+	expiryHandlers = append(expiryHandlers, handler)
+}
+
+// Function to handle the function which is to be done, when a session gets expired and deleted from the store.
+// Every node is notified of the expiry, so the local folders are deleted on every node, while the handlers and the
+// webhook are only run by the node taking the expiry lease.
+func handleExpiredSession(sessionId string) {
+	log.Printf("Message: Session with id \"%s\" expired.\n         Deleting all folders if exists.", sessionId)
+
+	err := session_folders.DeletePermFolder(sessionId)
+	if err != nil {
+		log.Printf("Error: %s", err.Error())
+	}
+
+	err = session_folders.DeleteTempFolder(sessionId)
+	if err != nil {
+		log.Printf("Error: %s", err.Error())
+	}
+
+	_, err = lease.Acquire("session_expiry:"+sessionId, expiryLeaseTTL)
+	if errors.Is(err, lease.ErrLeaseHeld) {
+		return
+	} else if err != nil {
+		log.Printf("Error: Could not take expiry lease of session id \"%s\": %s", sessionId, err.Error())
+		return
+	}
+
+	expiryHandlersMutex.RLock()
+	for _, handler := range expiryHandlers {
+		handler(sessionId)
+	}
+	expiryHandlersMutex.RUnlock()
+
+	webhook.Send(webhook_models.EventUploadExpired, sessionId, nil)
+}
@@ -0,0 +1,210 @@
+package session_store
+
+import (
+	chunk_models "ImageUploadMiniIo/pkg/image_chunks/models"
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// Interval at which the stores without expiry of their own look for the expired sessions.
+const expirySweepInterval = 10 * time.Second
+
+// Session store keeping the sessions in memory, meant for single node deployments and tests as nothing survives a restart.
+type MemoryStore struct {
+	mutex     sync.Mutex
+	sessions  map[string]*memorySession
+	onExpired func(sessionId string)
+	done      chan struct{}
+	wg        sync.WaitGroup
+}
+
+// Session held in a memory store, kept as json so that the callers never share the data held by the store.
+type memorySession struct {
+	jsonData   []byte
+	expiryTime time.Time
+}
+
+// Function to create an empty session store in memory, calling onExpired for every session once it expires.
+func NewMemoryStore(onExpired func(sessionId string)) *MemoryStore {
+	var store MemoryStore
+	store.sessions = make(map[string]*memorySession)
+	store.onExpired = onExpired
+	store.done = make(chan struct{})
+
+	// Starting a go routine to remove the expired sessions.
+	store.wg.Add(1)
+	go store.sweepExpired()
+
+	return &store
+}
+
+// Function to decode the session data kept as json.
+func decodeSessionData(jsonData []byte) (*chunk_models.SessionData, error) {
+	var sessionData chunk_models.SessionData
+	err := json.Unmarshal(jsonData, &sessionData)
+	if err != nil {
+		return nil, err
+	}
+
+	return &sessionData, nil
+}
+
+// Function to get a session which has not expired, nil if there is none. The mutex should be held by the caller.
+func (store *MemoryStore) getSession(sessionId string) *memorySession {
+	session, ok := store.sessions[sessionId]
+	if !ok || !time.Now().Before(session.expiryTime) {
+		return nil
+	}
+
+	return session
+}
+
+// Function to remove the expired sessions at every interval until the store is closed.
+func (store *MemoryStore) sweepExpired() {
+	defer store.wg.Done()
+
+	ticker := time.NewTicker(expirySweepInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-store.done:
+			return
+		case <-ticker.C:
+		}
+
+		// Remove the expired sessions under the mutex, and notify about them once it is released.
+		var expiredIds []string
+		store.mutex.Lock()
+		for sessionId, session := range store.sessions {
+			if !time.Now().Before(session.expiryTime) {
+				delete(store.sessions, sessionId)
+				expiredIds = append(expiredIds, sessionId)
+			}
+		}
+		store.mutex.Unlock()
+
+		for _, sessionId := range expiredIds {
+			store.onExpired(sessionId)
+		}
+	}
+}
+
+// Function to write the session data into memory against its session id.
+func (store *MemoryStore) Create(sessionData *chunk_models.SessionData, ttl time.Duration) error {
+	jsonData, err := json.Marshal(sessionData)
+	if err != nil {
+		return err
+	}
+
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	store.sessions[sessionData.SessionId] = &memorySession{jsonData: jsonData, expiryTime: time.Now().Add(ttl)}
+
+	return nil
+}
+
+// Function to get the session data and its remaining time to live for a particular session id.
+func (store *MemoryStore) Get(sessionId string) (*chunk_models.SessionData, time.Duration, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	session := store.getSession(sessionId)
+	if session == nil {
+		return nil, 0, ErrSessionNotFound
+	}
+
+	sessionData, err := decodeSessionData(session.jsonData)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return sessionData, time.Until(session.expiryTime), nil
+}
+
+// Function to check whether the session exists in memory and has not expired.
+func (store *MemoryStore) Exists(sessionId string) (bool, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	return store.getSession(sessionId) != nil, nil
+}
+
+// Function to update the session data for a particular session under the mutex, keeping its expiry time.
+// Returns the updated session data.
+func (store *MemoryStore) Update(sessionId string, update func(sessionData *chunk_models.SessionData)) (*chunk_models.SessionData, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	session := store.getSession(sessionId)
+	if session == nil {
+		return nil, ErrSessionNotFound
+	}
+
+	sessionData, err := decodeSessionData(session.jsonData)
+	if err != nil {
+		return nil, err
+	}
+
+	update(sessionData)
+
+	jsonData, err := json.Marshal(sessionData)
+	if err != nil {
+		return nil, err
+	}
+	session.jsonData = jsonData
+
+	return sessionData, nil
+}
+
+// Function to add a chunk to the received chunks of a particular session.
+func (store *MemoryStore) MarkReceived(sessionId string, chunkNumber int) (*chunk_models.SessionData, error) {
+	return store.Update(sessionId, func(sessionData *chunk_models.SessionData) {
+		markReceived(sessionData, chunkNumber)
+	})
+}
+
+// Function to add a chunk to the failed chunks of a particular session.
+// Returns the number of times the chunk has failed so far.
+func (store *MemoryStore) MarkFailed(sessionId string, chunkNumber int) (int, error) {
+	var failCount int
+	_, err := store.Update(sessionId, func(sessionData *chunk_models.SessionData) {
+		failCount = markFailed(sessionData, chunkNumber)
+	})
+
+	return failCount, err
+}
+
+// Function to remove a chunk from the failed chunks of a particular session.
+func (store *MemoryStore) ClearFailed(sessionId string, chunkNumber int) error {
+	_, err := store.Update(sessionId, func(sessionData *chunk_models.SessionData) {
+		clearFailed(sessionData, chunkNumber)
+	})
+
+	return err
+}
+
+// Function to delete a session from memory, if it exists.
+// Returns the deleted session data, nil if there was none.
+func (store *MemoryStore) Delete(sessionId string) (*chunk_models.SessionData, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	session := store.getSession(sessionId)
+	delete(store.sessions, sessionId)
+	if session == nil {
+		return nil, nil
+	}
+
+	return decodeSessionData(session.jsonData)
+}
+
+// Function to stop removing the expired sessions, waiting for the go routine doing it.
+func (store *MemoryStore) Close() error {
+	close(store.done)
+	store.wg.Wait()
+
+	return nil
+}
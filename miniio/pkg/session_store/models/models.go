@@ -0,0 +1,19 @@
+package models
+
+import (
+	chunk_models "ImageUploadMiniIo/pkg/image_chunks/models"
+	"time"
+)
+
+// Interface of the storage the upload sessions are kept in until they are finalized or expire.
+type SessionStore interface {
+	Create(sessionData *chunk_models.SessionData, ttl time.Duration) error
+	Get(sessionId string) (*chunk_models.SessionData, time.Duration, error)
+	Exists(sessionId string) (bool, error)
+	Update(sessionId string, update func(sessionData *chunk_models.SessionData)) (*chunk_models.SessionData, error)
+	MarkReceived(sessionId string, chunkNumber int) (*chunk_models.SessionData, error)
+	MarkFailed(sessionId string, chunkNumber int) (int, error)
+	ClearFailed(sessionId string, chunkNumber int) error
+	Delete(sessionId string) (*chunk_models.SessionData, error)
+	Close() error
+}
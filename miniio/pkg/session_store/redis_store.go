@@ -0,0 +1,473 @@
+package session_store
+
+import (
+	chunk_models "ImageUploadMiniIo/pkg/image_chunks/models"
+	redis_database "ImageUploadMiniIo/pkg/redis"
+	redis_models "ImageUploadMiniIo/pkg/redis/models"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"maps"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	mapset "github.com/deckarep/golang-set/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+)
+
+// Field of the session hash holding the session data, apart from the chunks kept in their own sets.
+const sessionDataField = "session_data"
+
+// Prefix of the fields of the session hash counting the failures of every chunk.
+const chunkFailCountFieldPrefix = "chunk_fail_count:"
+
+// Number of times an optimistic update is retried when the session is changed by another request in between.
+const maxUpdateRetries = 20
+
+// Script to add chunks to one of the chunk sets of a session, if the session exists, keeping the TTL of the session.
+// KEYS: session hash, chunk set. ARGV: chunk numbers.
+var addChunksScript = redis.NewScript(`
+local ttl = redis.call("PTTL", KEYS[1])
+if ttl == -2 then
+	return false
+end
+redis.call("SADD", KEYS[2], unpack(ARGV))
+if ttl > 0 then
+	redis.call("PEXPIRE", KEYS[2], ttl)
+end
+return 1
+`)
+
+// Script to add a chunk to the failed chunks of a session and count the failure, if the session exists, keeping the TTL of the session.
+// KEYS: session hash, failed ids set. ARGV: chunk number, fail count field.
+// Returns the number of times the chunk has failed so far.
+var markFailedScript = redis.NewScript(`
+local ttl = redis.call("PTTL", KEYS[1])
+if ttl == -2 then
+	return false
+end
+redis.call("SADD", KEYS[2], ARGV[1])
+if ttl > 0 then
+	redis.call("PEXPIRE", KEYS[2], ttl)
+end
+return redis.call("HINCRBY", KEYS[1], ARGV[2], 1)
+`)
+
+// Script to remove a chunk from the failed chunks of a session, if the session exists.
+// KEYS: session hash, failed ids set. ARGV: chunk number.
+var clearFailedScript = redis.NewScript(`
+if redis.call("EXISTS", KEYS[1]) == 0 then
+	return false
+end
+redis.call("SREM", KEYS[2], ARGV[1])
+return 1
+`)
+
+// Session store keeping every session in the redis, expired by the redis itself.
+// The session data is kept in a hash under the session id, along with the fail count of every chunk, while the
+// received and failed chunks are kept in sets of their own, so that parallel chunk requests update them atomically.
+type RedisStore struct {
+	redisClient   *redis_models.RedisClient
+	expireChannel *redis.PubSub
+	wg            sync.WaitGroup
+}
+
+// Function to create the session store on the redis client, calling onExpired for every session expired by the redis.
+func NewRedisStore(onExpired func(sessionId string)) *RedisStore {
+	var store RedisStore
+	store.redisClient = redis_database.GetRedisClient()
+
+	// Subscribe to keyspace notifications for expired keys.
+	store.expireChannel = store.redisClient.Client.PSubscribe(store.redisClient.Ctx, "__keyevent@0__:expired")
+	log.Println("Message: Subscribed to keyspace notifications.")
+
+	// Starting a go routine to handle the incoming messages, which will be used to delete the expired session folders.
+	store.wg.Add(1)
+	go store.handleMessages(onExpired)
+
+	return &store
+}
+
+// Function to handle the redis expired channel messages.
+func (store *RedisStore) handleMessages(onExpired func(sessionId string)) {
+	defer store.wg.Done()
+
+	for msg := range store.expireChannel.Channel() {
+		// Only the session hashes are named by a bare uuid, the other expired keys are skipped.
+		if _, err := uuid.Parse(msg.Payload); err != nil {
+			continue
+		}
+
+		onExpired(msg.Payload)
+	}
+}
+
+// Function to get the redis key of the set of received chunks for a particular session id.
+func getReceivedIdsKey(sessionId string) string {
+	return "session_received_ids:" + sessionId
+}
+
+// Function to get the redis key of the set of failed chunks for a particular session id.
+func getFailedIdsKey(sessionId string) string {
+	return "session_failed_ids:" + sessionId
+}
+
+// Function to get all the redis keys a particular session is kept in, the session hash first.
+func getSessionKeys(sessionId string) []string {
+	return []string{sessionId, getReceivedIdsKey(sessionId), getFailedIdsKey(sessionId)}
+}
+
+// Function to convert a set of chunk numbers into the members of a redis set.
+func toSetMembers(chunkNumbers []int) []interface{} {
+	members := make([]interface{}, 0, len(chunkNumbers))
+	for _, chunkNumber := range chunkNumbers {
+		members = append(members, chunkNumber)
+	}
+
+	return members
+}
+
+// Function to convert the members of a redis set into chunk numbers, in ascending order.
+func fromSetMembers(members []string) ([]int, error) {
+	chunkNumbers := make([]int, 0, len(members))
+	for _, member := range members {
+		chunkNumber, err := strconv.Atoi(member)
+		if err != nil {
+			return nil, fmt.Errorf("invalid chunk number \"%s\" in session: %w", member, err)
+		}
+		chunkNumbers = append(chunkNumbers, chunkNumber)
+	}
+	sort.Ints(chunkNumbers)
+
+	return chunkNumbers, nil
+}
+
+// Function to encode the session data kept in the session hash.
+// The chunks are kept in their own sets and fields, so they are left out of it.
+func encodeRedisSessionData(sessionData *chunk_models.SessionData) ([]byte, error) {
+	storedData := *sessionData
+	storedData.ReceivedIds = nil
+	storedData.FailedChunksInfo = nil
+	storedData.ChunkFailCounts = nil
+
+	return json.Marshal(storedData)
+}
+
+// Function to queue the commands writing the whole session into the redis, replacing whatever was there before.
+func (store *RedisStore) writeSession(pipe redis.Pipeliner, sessionData *chunk_models.SessionData, ttl time.Duration) error {
+	ctx := store.redisClient.Ctx
+	keys := getSessionKeys(sessionData.SessionId)
+
+	jsonData, err := encodeRedisSessionData(sessionData)
+	if err != nil {
+		return err
+	}
+
+	fields := map[string]interface{}{sessionDataField: jsonData}
+	for chunkNumber, failCount := range sessionData.ChunkFailCounts {
+		fields[chunkFailCountFieldPrefix+strconv.Itoa(chunkNumber)] = failCount
+	}
+
+	pipe.Del(ctx, keys...)
+	pipe.HSet(ctx, keys[0], fields)
+	if sessionData.ReceivedIds != nil && sessionData.ReceivedIds.Cardinality() > 0 {
+		pipe.SAdd(ctx, keys[1], toSetMembers(sessionData.ReceivedIds.ToSlice())...)
+	}
+	if len(sessionData.FailedChunksInfo) > 0 {
+		pipe.SAdd(ctx, keys[2], toSetMembers(sessionData.FailedChunksInfo)...)
+	}
+
+	// All the keys of the session expire together, a session without a TTL is kept until it is deleted.
+	if ttl > 0 {
+		for _, key := range keys {
+			pipe.PExpire(ctx, key, ttl)
+		}
+	}
+
+	return nil
+}
+
+// Function to queue the commands writing the changes an update has made to a session.
+// Only the chunks added or removed by the update are written to the sets, so that the chunks marked by other
+// requests in the meantime are kept.
+func (store *RedisStore) writeSessionChanges(pipe redis.Pipeliner, before *chunk_models.SessionData, after *chunk_models.SessionData) error {
+	ctx := store.redisClient.Ctx
+	keys := getSessionKeys(after.SessionId)
+
+	jsonData, err := encodeRedisSessionData(after)
+	if err != nil {
+		return err
+	}
+
+	// Write the session data along with the fail counts which have changed, and remove the ones dropped.
+	fields := map[string]interface{}{sessionDataField: jsonData}
+	for chunkNumber, failCount := range after.ChunkFailCounts {
+		if before.ChunkFailCounts[chunkNumber] != failCount {
+			fields[chunkFailCountFieldPrefix+strconv.Itoa(chunkNumber)] = failCount
+		}
+	}
+	pipe.HSet(ctx, keys[0], fields)
+	for chunkNumber := range before.ChunkFailCounts {
+		if _, ok := after.ChunkFailCounts[chunkNumber]; !ok {
+			pipe.HDel(ctx, keys[0], chunkFailCountFieldPrefix+strconv.Itoa(chunkNumber))
+		}
+	}
+
+	// Write the chunks added to and removed from the sets.
+	store.writeSetChanges(pipe, keys[0], keys[1], before.ReceivedIds, after.ReceivedIds)
+	store.writeSetChanges(pipe, keys[0], keys[2], mapset.NewSet[int](before.FailedChunksInfo...), mapset.NewSet[int](after.FailedChunksInfo...))
+
+	return nil
+}
+
+// Function to queue the commands adding and removing the chunks which differ between two sets of a session.
+// A set created by the additions is given the TTL of the session hash.
+func (store *RedisStore) writeSetChanges(pipe redis.Pipeliner, sessionKey string, setKey string, before mapset.Set[int], after mapset.Set[int]) {
+	ctx := store.redisClient.Ctx
+	if before == nil {
+		before = mapset.NewSet[int]()
+	}
+	if after == nil {
+		after = mapset.NewSet[int]()
+	}
+
+	if added := after.Difference(before); added.Cardinality() > 0 {
+		addChunksScript.Eval(ctx, pipe, []string{sessionKey, setKey}, toSetMembers(added.ToSlice())...)
+	}
+	if removed := before.Difference(after); removed.Cardinality() > 0 {
+		pipe.SRem(ctx, setKey, toSetMembers(removed.ToSlice())...)
+	}
+}
+
+// Type of the functions running the commands queued in a pipeline, either within a transaction or not.
+type pipelinedFunc func(ctx context.Context, fn func(redis.Pipeliner) error) ([]redis.Cmder, error)
+
+// Function to read the whole session from the redis, with all of its keys read through a single pipeline.
+// Outside of a watched update, the pipeline should be a transaction, so that the keys agree with each other.
+func (store *RedisStore) readSession(pipelined pipelinedFunc, sessionId string) (*chunk_models.SessionData, time.Duration, error) {
+	ctx := store.redisClient.Ctx
+	keys := getSessionKeys(sessionId)
+
+	var fieldsCmd *redis.StringStringMapCmd
+	var receivedIdsCmd, failedIdsCmd *redis.StringSliceCmd
+	var ttlCmd *redis.DurationCmd
+	_, err := pipelined(ctx, func(pipe redis.Pipeliner) error {
+		fieldsCmd = pipe.HGetAll(ctx, keys[0])
+		receivedIdsCmd = pipe.SMembers(ctx, keys[1])
+		failedIdsCmd = pipe.SMembers(ctx, keys[2])
+		ttlCmd = pipe.PTTL(ctx, keys[0])
+		return nil
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return decodeRedisSession(fieldsCmd.Val(), receivedIdsCmd.Val(), failedIdsCmd.Val(), ttlCmd.Val())
+}
+
+// Function to build the session data from the session hash and the sets of received and failed chunks.
+func decodeRedisSession(fields map[string]string, receivedIds []string, failedIds []string, ttl time.Duration) (*chunk_models.SessionData, time.Duration, error) {
+	// The session hash always holds the session data field, an empty hash means the session does not exist.
+	jsonData, ok := fields[sessionDataField]
+	if !ok {
+		return nil, 0, ErrSessionNotFound
+	}
+
+	sessionData, err := decodeSessionData([]byte(jsonData))
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Set the received chunks.
+	receivedChunkNumbers, err := fromSetMembers(receivedIds)
+	if err != nil {
+		return nil, 0, err
+	}
+	sessionData.ReceivedIds = mapset.NewSet[int](receivedChunkNumbers...)
+
+	// Set the failed chunks.
+	sessionData.FailedChunksInfo, err = fromSetMembers(failedIds)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Set the fail count of every chunk.
+	sessionData.ChunkFailCounts = make(map[int]int)
+	for field, value := range fields {
+		chunkField, ok := strings.CutPrefix(field, chunkFailCountFieldPrefix)
+		if !ok {
+			continue
+		}
+
+		chunkNumber, err := strconv.Atoi(chunkField)
+		if err != nil {
+			return nil, 0, fmt.Errorf("invalid chunk number \"%s\" in session: %w", chunkField, err)
+		}
+		failCount, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, 0, fmt.Errorf("invalid fail count \"%s\" in session: %w", value, err)
+		}
+		sessionData.ChunkFailCounts[chunkNumber] = failCount
+	}
+
+	return sessionData, ttl, nil
+}
+
+// Function to write the session data to the redis against its session id.
+func (store *RedisStore) Create(sessionData *chunk_models.SessionData, ttl time.Duration) error {
+	_, err := store.redisClient.Client.TxPipelined(store.redisClient.Ctx, func(pipe redis.Pipeliner) error {
+		return store.writeSession(pipe, sessionData, ttl)
+	})
+
+	return err
+}
+
+// Function to get the session data and its remaining time to live from the redis for a particular session id.
+func (store *RedisStore) Get(sessionId string) (*chunk_models.SessionData, time.Duration, error) {
+	return store.readSession(store.redisClient.Client.TxPipelined, sessionId)
+}
+
+// Function to check whether the session exists in the redis and has not expired.
+func (store *RedisStore) Exists(sessionId string) (bool, error) {
+	// Result = 0 --> does not exist & Result = 1 --> exists.
+	result, err := store.redisClient.Client.Exists(store.redisClient.Ctx, sessionId).Result()
+	if err != nil {
+		return false, err
+	}
+
+	return result == 1, nil
+}
+
+// Function to read, update and write back the session data for a particular session, keeping its TTL.
+// The session hash is watched, so that the update is retried when another request changes it in between, while
+// the chunk sets are only written with the chunks the update has added or removed.
+// Returns the updated session data.
+func (store *RedisStore) Update(sessionId string, update func(sessionData *chunk_models.SessionData)) (*chunk_models.SessionData, error) {
+	var sessionData *chunk_models.SessionData
+	transaction := func(tx *redis.Tx) error {
+		// Get the session data, outside of the transaction as the session hash is watched.
+		var err error
+		sessionData, _, err = store.readSession(tx.Pipelined, sessionId)
+		if err != nil {
+			return err
+		}
+
+		// Keep a copy of the chunks before the update, to find out what it has changed.
+		var before chunk_models.SessionData
+		before.ReceivedIds = sessionData.ReceivedIds.Clone()
+		before.FailedChunksInfo = slices.Clone(sessionData.FailedChunksInfo)
+		before.ChunkFailCounts = maps.Clone(sessionData.ChunkFailCounts)
+
+		update(sessionData)
+
+		// Write the changes, only if the session hash has not changed since it was read.
+		_, err = tx.TxPipelined(store.redisClient.Ctx, func(pipe redis.Pipeliner) error {
+			return store.writeSessionChanges(pipe, &before, sessionData)
+		})
+
+		return err
+	}
+
+	for retry := 0; retry < maxUpdateRetries; retry++ {
+		err := store.redisClient.Client.Watch(store.redisClient.Ctx, transaction, sessionId)
+		if errors.Is(err, redis.TxFailedErr) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		return sessionData, nil
+	}
+
+	return nil, fmt.Errorf("session \"%s\" kept changing, update given up after %d retries", sessionId, maxUpdateRetries)
+}
+
+// Function to add a chunk to the received chunks of a particular session.
+// Returns the session data after the chunk has been added.
+func (store *RedisStore) MarkReceived(sessionId string, chunkNumber int) (*chunk_models.SessionData, error) {
+	keys := getSessionKeys(sessionId)
+	err := addChunksScript.Run(store.redisClient.Ctx, store.redisClient.Client, keys[:2], chunkNumber).Err()
+	if err == redis.Nil {
+		return nil, ErrSessionNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	sessionData, _, err := store.Get(sessionId)
+	if err != nil {
+		return nil, err
+	}
+
+	return sessionData, nil
+}
+
+// Function to add a chunk to the failed chunks of a particular session.
+// Returns the number of times the chunk has failed so far.
+func (store *RedisStore) MarkFailed(sessionId string, chunkNumber int) (int, error) {
+	keys := getSessionKeys(sessionId)
+	failCount, err := markFailedScript.Run(store.redisClient.Ctx, store.redisClient.Client, []string{keys[0], keys[2]}, chunkNumber, chunkFailCountFieldPrefix+strconv.Itoa(chunkNumber)).Int()
+	if err == redis.Nil {
+		return 0, ErrSessionNotFound
+	} else if err != nil {
+		return 0, err
+	}
+
+	return failCount, nil
+}
+
+// Function to remove a chunk from the failed chunks of a particular session.
+func (store *RedisStore) ClearFailed(sessionId string, chunkNumber int) error {
+	keys := getSessionKeys(sessionId)
+	err := clearFailedScript.Run(store.redisClient.Ctx, store.redisClient.Client, []string{keys[0], keys[2]}, chunkNumber).Err()
+	if err == redis.Nil {
+		return ErrSessionNotFound
+	}
+
+	return err
+}
+
+// Function to delete a session from the redis, if it exists.
+// Returns the deleted session data, nil if there was none.
+func (store *RedisStore) Delete(sessionId string) (*chunk_models.SessionData, error) {
+	ctx := store.redisClient.Ctx
+	keys := getSessionKeys(sessionId)
+
+	// Read and delete all the keys of the session within a single transaction.
+	var fieldsCmd *redis.StringStringMapCmd
+	var receivedIdsCmd, failedIdsCmd *redis.StringSliceCmd
+	_, err := store.redisClient.Client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		fieldsCmd = pipe.HGetAll(ctx, keys[0])
+		receivedIdsCmd = pipe.SMembers(ctx, keys[1])
+		failedIdsCmd = pipe.SMembers(ctx, keys[2])
+		pipe.Del(ctx, keys...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sessionData, _, err := decodeRedisSession(fieldsCmd.Val(), receivedIdsCmd.Val(), failedIdsCmd.Val(), 0)
+	if errors.Is(err, ErrSessionNotFound) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return sessionData, nil
+}
+
+// Function to stop handling the expired sessions.
+// Closing the expire channel ends the go routine handling its messages, which is then waited for.
+func (store *RedisStore) Close() error {
+	err := store.expireChannel.Close()
+	store.wg.Wait()
+
+	return err
+}
@@ -0,0 +1,122 @@
+package session_store
+
+import (
+	chunk_models "ImageUploadMiniIo/pkg/image_chunks/models"
+	redis_database "ImageUploadMiniIo/pkg/redis"
+	session_store_models "ImageUploadMiniIo/pkg/session_store/models"
+	"errors"
+	"io/fs"
+	"log"
+	"os"
+	"slices"
+
+	"github.com/joho/godotenv"
+)
+
+// Names of the session stores selectable by configuration.
+const (
+	StoreRedis  = "redis"
+	StoreMemory = "memory"
+	StoreBolt   = "bolt"
+)
+
+// Error returned when the session does not exist in the store, either because it has expired or it never existed.
+var ErrSessionNotFound = errors.New("session has expired or does not exist")
+
+// Declaring the session store selected by configuration.
+var sessionStore session_store_models.SessionStore
+
+func init() {
+	// Loading the environment variables, which may also be set without an environment file.
+	err := godotenv.Load(".env")
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		log.Fatalf("Error: Problem while loading environment variables.")
+		os.Exit(1)
+	}
+
+	switch getStoreType() {
+	case StoreMemory:
+		sessionStore = NewMemoryStore(handleExpiredSession)
+		log.Println("Message: Using in-memory session store successfully.")
+	case StoreBolt:
+		// Loading the path of the database file, created if it does not exist.
+		boltPath := os.Getenv("SESSION_STORE_PATH")
+		if boltPath == "" {
+			boltPath = "sessions.db"
+		}
+
+		sessionStore, err = NewBoltStore(boltPath, handleExpiredSession)
+		if err != nil {
+			log.Fatalf("Error: Problem while opening bolt session store.       %s", err.Error())
+			os.Exit(1)
+		}
+		log.Println("Message: Using bolt session store successfully.")
+	default:
+		if !redis_database.IsConfigured() {
+			log.Fatalf("Error: Redis session store selected without a redis address.")
+			os.Exit(1)
+		}
+		sessionStore = NewRedisStore(handleExpiredSession)
+		log.Println("Message: Using redis session store successfully.")
+	}
+}
+
+// Function to get the type of the session store selected by configuration.
+// Unless configured otherwise, the sessions are kept in the redis if there is one, and in memory if not.
+func getStoreType() string {
+	// Loading the environment variables.
+	storeType := os.Getenv("SESSION_STORE")
+	if storeType != "" {
+		return storeType
+	}
+	if redis_database.IsConfigured() {
+		return StoreRedis
+	}
+
+	return StoreMemory
+}
+
+// Function to check whether the sessions are kept in a store shared by the nodes, rather than in the node itself.
+func IsSharedStore() bool {
+	return getStoreType() == StoreRedis
+}
+
+// Function to get the session store.
+func GetSessionStore() session_store_models.SessionStore {
+	return sessionStore
+}
+
+// Function to shut down the session store, stopping the handling of expired sessions.
+func ShutDown() {
+	err := sessionStore.Close()
+	if err != nil {
+		log.Printf("Error: Problem while closing session store: %s", err.Error())
+	}
+}
+
+// Function to add a chunk to the received chunks of the session data.
+func markReceived(sessionData *chunk_models.SessionData, chunkNumber int) {
+	sessionData.ReceivedIds.Add(chunkNumber)
+}
+
+// Function to add a chunk to the failed chunks of the session data, if not already present, and count the failure.
+// Returns the number of times the chunk has failed so far.
+func markFailed(sessionData *chunk_models.SessionData, chunkNumber int) int {
+	if !slices.Contains(sessionData.FailedChunksInfo, chunkNumber) {
+		sessionData.FailedChunksInfo = append(sessionData.FailedChunksInfo, chunkNumber)
+	}
+
+	if sessionData.ChunkFailCounts == nil {
+		sessionData.ChunkFailCounts = make(map[int]int)
+	}
+	sessionData.ChunkFailCounts[chunkNumber]++
+
+	return sessionData.ChunkFailCounts[chunkNumber]
+}
+
+// Function to remove a chunk from the failed chunks of the session data, once it has been re-sent successfully.
+func clearFailed(sessionData *chunk_models.SessionData, chunkNumber int) {
+	sessionData.FailedChunksInfo = slices.DeleteFunc(sessionData.FailedChunksInfo, func(failedChunkNumber int) bool {
+		return failedChunkNumber == chunkNumber
+	})
+}
@@ -0,0 +1,100 @@
+package session_store
+
+import (
+	chunk_models "ImageUploadMiniIo/pkg/image_chunks/models"
+	session_store_models "ImageUploadMiniIo/pkg/session_store/models"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+
+	mapset "github.com/deckarep/golang-set/v2"
+)
+
+func TestSessionStores(t *testing.T) {
+	boltStore, err := NewBoltStore(filepath.Join(t.TempDir(), "sessions.db"), func(sessionId string) {})
+	if err != nil {
+		t.Fatalf("could not open bolt store: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		store session_store_models.SessionStore
+	}{
+		{name: "memory", store: NewMemoryStore(func(sessionId string) {})},
+		{name: "bolt", store: boltStore},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			defer test.store.Close()
+
+			var sessionData chunk_models.SessionData
+			sessionData.SessionId = "session"
+			sessionData.ReceivedIds = mapset.NewSet[int]()
+			if err := test.store.Create(&sessionData, time.Hour); err != nil {
+				t.Fatalf("could not create session: %v", err)
+			}
+			if err := test.store.Create(&chunk_models.SessionData{SessionId: "expired", ReceivedIds: mapset.NewSet[int]()}, time.Millisecond); err != nil {
+				t.Fatalf("could not create session: %v", err)
+			}
+			time.Sleep(5 * time.Millisecond)
+
+			if _, err := test.store.MarkReceived("session", 1); err != nil {
+				t.Fatalf("could not mark chunk received: %v", err)
+			}
+			test.store.MarkFailed("session", 2)
+			test.store.MarkFailed("session", 3)
+			if failCount, err := test.store.MarkFailed("session", 2); err != nil || failCount != 2 {
+				t.Errorf("fail count = %d, %v, want 2", failCount, err)
+			}
+			if err := test.store.ClearFailed("session", 3); err != nil {
+				t.Fatalf("could not clear failed chunk: %v", err)
+			}
+
+			stored, ttl, err := test.store.Get("session")
+			if err != nil {
+				t.Fatalf("could not get session: %v", err)
+			}
+			if !stored.ReceivedIds.Equal(mapset.NewSet(1)) || !slices.Equal(stored.FailedChunksInfo, []int{2}) {
+				t.Errorf("received %v and failed %v, want [1] and [2]", stored.ReceivedIds.ToSlice(), stored.FailedChunksInfo)
+			}
+			if ttl <= time.Hour-time.Minute || ttl > time.Hour {
+				t.Errorf("ttl = %s, want the expiry kept", ttl)
+			}
+
+			if _, _, err := test.store.Get("expired"); err != ErrSessionNotFound {
+				t.Errorf("expired session error = %v, want %v", err, ErrSessionNotFound)
+			}
+			if _, err := test.store.MarkReceived("unknown", 1); err != ErrSessionNotFound {
+				t.Errorf("unknown session error = %v, want %v", err, ErrSessionNotFound)
+			}
+
+			if deleted, err := test.store.Delete("session"); err != nil || deleted == nil {
+				t.Fatalf("deleted = %v, %v, want the session", deleted, err)
+			}
+			if exists, err := test.store.Exists("session"); err != nil || exists {
+				t.Errorf("exists after delete = %v, %v, want false", exists, err)
+			}
+		})
+	}
+}
+
+func TestHandleExpiredSession(t *testing.T) {
+	sessionId := "expired-" + strconv.FormatInt(time.Now().UnixNano(), 10)
+	handled := 0
+	AddExpiryHandler(func(expiredId string) {
+		if expiredId == sessionId {
+			handled++
+		}
+	})
+
+	// Every node is notified of the expiry, but only one of them should handle it.
+	handleExpiredSession(sessionId)
+	handleExpiredSession(sessionId)
+
+	if handled != 1 {
+		t.Errorf("handled = %d times, want once", handled)
+	}
+}
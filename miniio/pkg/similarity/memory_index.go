@@ -0,0 +1,83 @@
+package similarity
+
+import (
+	"maps"
+	"sync"
+)
+
+// Index keeping the perceptual hashes in memory, meant for single node deployments and tests as nothing survives a restart.
+type MemoryIndex struct {
+	mutex  sync.RWMutex
+	hashes map[string]string
+	bands  map[string]map[string]struct{}
+}
+
+// Function to create an empty index in memory.
+func NewMemoryIndex() *MemoryIndex {
+	return &MemoryIndex{hashes: make(map[string]string), bands: make(map[string]map[string]struct{})}
+}
+
+// Function to add an object to the index in memory and to its bands.
+func (index *MemoryIndex) AddObject(objectName string, hash string, bandKeys []string) error {
+	index.mutex.Lock()
+	defer index.mutex.Unlock()
+
+	index.hashes[objectName] = hash
+	for _, bandKey := range bandKeys {
+		if index.bands[bandKey] == nil {
+			index.bands[bandKey] = make(map[string]struct{})
+		}
+		index.bands[bandKey][objectName] = struct{}{}
+	}
+
+	return nil
+}
+
+// Function to get the perceptual hash of an object indexed in memory, empty if the object is not indexed.
+func (index *MemoryIndex) GetObjectHash(objectName string) (string, error) {
+	index.mutex.RLock()
+	defer index.mutex.RUnlock()
+
+	return index.hashes[objectName], nil
+}
+
+// Function to get the perceptual hashes of all the objects indexed in memory.
+func (index *MemoryIndex) GetAllHashes() (map[string]string, error) {
+	index.mutex.RLock()
+	defer index.mutex.RUnlock()
+
+	return maps.Clone(index.hashes), nil
+}
+
+// Function to remove an object from the index in memory and from its bands.
+func (index *MemoryIndex) RemoveObject(objectName string, bandKeys []string) error {
+	index.mutex.Lock()
+	defer index.mutex.Unlock()
+
+	delete(index.hashes, objectName)
+	for _, bandKey := range bandKeys {
+		delete(index.bands[bandKey], objectName)
+		if len(index.bands[bandKey]) == 0 {
+			delete(index.bands, bandKey)
+		}
+	}
+
+	return nil
+}
+
+// Function to get the perceptual hashes of the objects in any of the given bands.
+func (index *MemoryIndex) GetBandHashes(bandKeys []string) (map[string]string, error) {
+	index.mutex.RLock()
+	defer index.mutex.RUnlock()
+
+	candidates := make(map[string]string)
+	for _, bandKey := range bandKeys {
+		for objectName := range index.bands[bandKey] {
+			if hash, ok := index.hashes[objectName]; ok {
+				candidates[objectName] = hash
+			}
+		}
+	}
+
+	return candidates, nil
+}
@@ -0,0 +1,23 @@
+package models
+
+// Interface of the backend the perceptual hashes of the stored objects are indexed in.
+// Every object is also added to the bands of its hash, so that the objects sharing a band can be looked up.
+type Index interface {
+	AddObject(objectName string, hash string, bandKeys []string) error
+	GetObjectHash(objectName string) (string, error)
+	GetAllHashes() (map[string]string, error)
+	GetBandHashes(bandKeys []string) (map[string]string, error)
+	RemoveObject(objectName string, bandKeys []string) error
+}
+
+type SimilarObject struct {
+	ObjectName     string `json:"object_name"`
+	PerceptualHash string `json:"perceptual_hash"`
+	Distance       int    `json:"distance"`
+}
+
+type SimilarObjectsResponse struct {
+	PerceptualHash string          `json:"perceptual_hash"`
+	MaxDistance    int             `json:"max_distance"`
+	Matches        []SimilarObject `json:"matches"`
+}
@@ -0,0 +1,99 @@
+package similarity
+
+import (
+	redis_database "ImageUploadMiniIo/pkg/redis"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// Redis hash holding the perceptual hash of every indexed object, keyed by the object name.
+const hashIndexKey = "perceptual_hashes"
+
+// Index keeping the perceptual hashes in a redis hash, and the bands in a redis set each.
+type RedisIndex struct{}
+
+// Function to create an index on the redis client.
+func NewRedisIndex() *RedisIndex {
+	return &RedisIndex{}
+}
+
+// Function to add an object to the redis hash and to the sets of its bands.
+func (index *RedisIndex) AddObject(objectName string, hash string, bandKeys []string) error {
+	// Get the redis client.
+	redisClient := redis_database.GetRedisClient()
+
+	_, err := redisClient.Client.TxPipelined(redisClient.Ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(redisClient.Ctx, hashIndexKey, objectName, hash)
+		for _, bandKey := range bandKeys {
+			pipe.SAdd(redisClient.Ctx, bandKey, objectName)
+		}
+		return nil
+	})
+
+	return err
+}
+
+// Function to remove an object from the redis hash and from the sets of its bands.
+func (index *RedisIndex) RemoveObject(objectName string, bandKeys []string) error {
+	// Get the redis client.
+	redisClient := redis_database.GetRedisClient()
+
+	_, err := redisClient.Client.TxPipelined(redisClient.Ctx, func(pipe redis.Pipeliner) error {
+		pipe.HDel(redisClient.Ctx, hashIndexKey, objectName)
+		for _, bandKey := range bandKeys {
+			pipe.SRem(redisClient.Ctx, bandKey, objectName)
+		}
+		return nil
+	})
+
+	return err
+}
+
+// Function to get the perceptual hash of an object from the redis hash, empty if the object is not indexed.
+func (index *RedisIndex) GetObjectHash(objectName string) (string, error) {
+	// Get the redis client.
+	redisClient := redis_database.GetRedisClient()
+
+	hash, err := redisClient.Client.HGet(redisClient.Ctx, hashIndexKey, objectName).Result()
+	if err == redis.Nil {
+		return "", nil
+	}
+
+	return hash, err
+}
+
+// Function to get the perceptual hashes of all the indexed objects.
+func (index *RedisIndex) GetAllHashes() (map[string]string, error) {
+	// Get the redis client.
+	redisClient := redis_database.GetRedisClient()
+
+	return redisClient.Client.HGetAll(redisClient.Ctx, hashIndexKey).Result()
+}
+
+// Function to get the perceptual hashes of the objects in any of the given bands.
+func (index *RedisIndex) GetBandHashes(bandKeys []string) (map[string]string, error) {
+	// Get the redis client.
+	redisClient := redis_database.GetRedisClient()
+
+	objectNames, err := redisClient.Client.SUnion(redisClient.Ctx, bandKeys...).Result()
+	if err != nil {
+		return nil, err
+	}
+	if len(objectNames) == 0 {
+		return map[string]string{}, nil
+	}
+
+	hashes, err := redisClient.Client.HMGet(redisClient.Ctx, hashIndexKey, objectNames...).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := make(map[string]string, len(objectNames))
+	for i, hash := range hashes {
+		if hash, ok := hash.(string); ok {
+			candidates[objectNames[i]] = hash
+		}
+	}
+
+	return candidates, nil
+}
@@ -0,0 +1,173 @@
+package similarity
+
+import (
+	redis_database "ImageUploadMiniIo/pkg/redis"
+	similarity_models "ImageUploadMiniIo/pkg/similarity/models"
+	"errors"
+	"fmt"
+	"math/bits"
+	"sort"
+	"strconv"
+)
+
+// Number of bands the 64 bits of a perceptual hash are split into for the band index.
+// Two hashes within a Hamming distance smaller than the number of bands share at least one band,
+// so those searches only need to compare the objects sharing a band with the searched hash.
+const bandCount = 8
+
+// Error returned when a perceptual hash is not made of 16 hexadecimal characters.
+var ErrInvalidHash = errors.New("perceptual hash should be 16 hexadecimal characters")
+
+// Declaring the index the perceptual hashes are kept in, the redis when configured so that it is shared by the nodes.
+var index similarity_models.Index
+
+// Declaring the check of whether an indexed object is still stored, set by the package storing the objects.
+var objectExists func(objectName string) (bool, error)
+
+// Init() function to select the index.
+func init() {
+	if redis_database.IsConfigured() {
+		index = NewRedisIndex()
+	} else {
+		index = NewMemoryIndex()
+	}
+}
+
+// Function to parse a perceptual hash from its hexadecimal form.
+func ParseHash(hash string) (uint64, error) {
+	if len(hash) != 16 {
+		return 0, ErrInvalidHash
+	}
+
+	value, err := strconv.ParseUint(hash, 16, 64)
+	if err != nil {
+		return 0, ErrInvalidHash
+	}
+
+	return value, nil
+}
+
+// Function to get the keys of the bands of a perceptual hash, one for each of its bytes.
+func getBandKeys(value uint64) []string {
+	bandKeys := make([]string, 0, bandCount)
+	for band := 0; band < bandCount; band++ {
+		bandKeys = append(bandKeys, fmt.Sprintf("perceptual_hash_band:%d:%02x", band, (value>>(band*8))&0xFF))
+	}
+
+	return bandKeys
+}
+
+// Function to add an object to the index under its perceptual hash.
+func IndexObject(objectName string, hash string) error {
+	value, err := ParseHash(hash)
+	if err != nil {
+		return err
+	}
+
+	return index.AddObject(objectName, hash, getBandKeys(value))
+}
+
+// Function to set the check of whether an indexed object is still stored, so that the removed objects are not matched.
+func SetObjectExistsCheck(check func(objectName string) (bool, error)) {
+	objectExists = check
+}
+
+// Function to remove an object from the index, if it is indexed.
+func RemoveObject(objectName string) error {
+	hash, err := index.GetObjectHash(objectName)
+	if err != nil || hash == "" {
+		return err
+	}
+
+	value, err := ParseHash(hash)
+	if err != nil {
+		return index.RemoveObject(objectName, nil)
+	}
+
+	return index.RemoveObject(objectName, getBandKeys(value))
+}
+
+// Function to check whether an indexed object is still stored, removing it from the index if it is not.
+func isObjectStored(objectName string) (bool, error) {
+	if objectExists == nil {
+		return true, nil
+	}
+
+	exists, err := objectExists(objectName)
+	if err != nil || exists {
+		return exists, err
+	}
+
+	return false, RemoveObject(objectName)
+}
+
+// Function to get the perceptual hash of an indexed object, empty if the object is not indexed.
+func GetObjectHash(objectName string) (string, error) {
+	return index.GetObjectHash(objectName)
+}
+
+// Function to get the perceptual hashes of the objects which may lie within the given distance of the searched hash.
+// Below the number of bands only the objects sharing a band are returned, otherwise the whole index is.
+func getCandidates(value uint64, maxDistance int) (map[string]string, error) {
+	if maxDistance >= bandCount {
+		return index.GetAllHashes()
+	}
+
+	return index.GetBandHashes(getBandKeys(value))
+}
+
+// Function to find the indexed objects whose perceptual hash lies within the given Hamming distance of the searched hash.
+// The matches are ordered from the closest, and at most limit of them are returned.
+// An object which is no longer stored is removed from the index and left out of the matches.
+func FindSimilarObjects(hash string, maxDistance int, limit int) ([]similarity_models.SimilarObject, error) {
+	value, err := ParseHash(hash)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates, err := getCandidates(value, maxDistance)
+	if err != nil {
+		return nil, err
+	}
+
+	matches := make([]similarity_models.SimilarObject, 0)
+	for objectName, candidateHash := range candidates {
+		candidateValue, err := ParseHash(candidateHash)
+		if err != nil {
+			continue
+		}
+
+		distance := bits.OnesCount64(value ^ candidateValue)
+		if distance <= maxDistance {
+			matches = append(matches, similarity_models.SimilarObject{
+				ObjectName:     objectName,
+				PerceptualHash: candidateHash,
+				Distance:       distance,
+			})
+		}
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].Distance != matches[j].Distance {
+			return matches[i].Distance < matches[j].Distance
+		}
+		return matches[i].ObjectName < matches[j].ObjectName
+	})
+
+	storedMatches := make([]similarity_models.SimilarObject, 0, min(len(matches), limit))
+	for _, match := range matches {
+		if len(storedMatches) == limit {
+			break
+		}
+
+		stored, err := isObjectStored(match.ObjectName)
+		if err != nil {
+			return nil, err
+		}
+		if stored {
+			storedMatches = append(storedMatches, match)
+		}
+	}
+
+	return storedMatches, nil
+}
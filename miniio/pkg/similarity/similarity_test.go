@@ -0,0 +1,160 @@
+package similarity
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParseHash(t *testing.T) {
+	tests := []struct {
+		name      string
+		hash      string
+		wantValue uint64
+		wantErr   bool
+	}{
+		{name: "zero", hash: "0000000000000000", wantValue: 0},
+		{name: "lower case", hash: "00000000000000ff", wantValue: 0xff},
+		{name: "upper case", hash: "FFFFFFFFFFFFFFFF", wantValue: ^uint64(0)},
+		{name: "too short", hash: "ff", wantErr: true},
+		{name: "too long", hash: "00000000000000000", wantErr: true},
+		{name: "not hexadecimal", hash: "000000000000000g", wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			value, err := ParseHash(test.hash)
+			if test.wantErr {
+				if err != ErrInvalidHash {
+					t.Fatalf("error = %v, want %v", err, ErrInvalidHash)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if value != test.wantValue {
+				t.Errorf("value = %x, want %x", value, test.wantValue)
+			}
+		})
+	}
+}
+
+func TestGetBandKeys(t *testing.T) {
+	tests := []struct {
+		name  string
+		value uint64
+		want  []string
+	}{
+		{
+			name:  "zero",
+			value: 0,
+			want: []string{
+				"perceptual_hash_band:0:00", "perceptual_hash_band:1:00", "perceptual_hash_band:2:00", "perceptual_hash_band:3:00",
+				"perceptual_hash_band:4:00", "perceptual_hash_band:5:00", "perceptual_hash_band:6:00", "perceptual_hash_band:7:00",
+			},
+		},
+		{
+			name:  "bytes in order from the lowest",
+			value: 0x0123456789abcdef,
+			want: []string{
+				"perceptual_hash_band:0:ef", "perceptual_hash_band:1:cd", "perceptual_hash_band:2:ab", "perceptual_hash_band:3:89",
+				"perceptual_hash_band:4:67", "perceptual_hash_band:5:45", "perceptual_hash_band:6:23", "perceptual_hash_band:7:01",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			bandKeys := getBandKeys(test.value)
+			if !slices.Equal(bandKeys, test.want) {
+				t.Errorf("band keys = %v, want %v", bandKeys, test.want)
+			}
+		})
+	}
+}
+
+func TestFindSimilarObjects(t *testing.T) {
+	indexed := map[string]string{
+		"exact":   "00000000000000ff",
+		"one":     "00000000000000fe",
+		"three":   "00000000000000f8",
+		"eight":   "01010101010101fe",
+		"far":     "ffffffffffffff00",
+		"removed": "00000000000000fd",
+	}
+	stored := func(objectName string) (bool, error) {
+		return objectName != "removed", nil
+	}
+
+	tests := []struct {
+		name        string
+		maxDistance int
+		limit       int
+		want        []string
+		wantDropped bool
+	}{
+		{name: "exact match only", maxDistance: 0, limit: 10, want: []string{"exact"}},
+		{name: "within the bands", maxDistance: 3, limit: 10, want: []string{"exact", "one", "three"}, wantDropped: true},
+		{name: "limited", maxDistance: 3, limit: 2, want: []string{"exact", "one"}},
+		{name: "beyond the bands", maxDistance: 9, limit: 10, want: []string{"exact", "one", "three", "eight"}, wantDropped: true},
+		{name: "whole index", maxDistance: 64, limit: 10, want: []string{"exact", "one", "three", "eight", "far"}, wantDropped: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			index = NewMemoryIndex()
+			SetObjectExistsCheck(stored)
+			defer SetObjectExistsCheck(nil)
+			for objectName, hash := range indexed {
+				if err := IndexObject(objectName, hash); err != nil {
+					t.Fatalf("could not index %s: %v", objectName, err)
+				}
+			}
+
+			matches, err := FindSimilarObjects("00000000000000ff", test.maxDistance, test.limit)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			objectNames := make([]string, 0, len(matches))
+			for _, match := range matches {
+				objectNames = append(objectNames, match.ObjectName)
+			}
+			if !slices.Equal(objectNames, test.want) {
+				t.Errorf("matches = %v, want %v", objectNames, test.want)
+			}
+
+			// The object no longer stored is dropped from the index once it has been matched.
+			hash, _ := GetObjectHash("removed")
+			if (hash == "") != test.wantDropped {
+				t.Errorf("removed object hash = %q, want dropped %v", hash, test.wantDropped)
+			}
+		})
+	}
+}
+
+func TestRemoveObject(t *testing.T) {
+	index = NewMemoryIndex()
+	if err := IndexObject("kept", "00000000000000ff"); err != nil {
+		t.Fatalf("could not index: %v", err)
+	}
+	if err := IndexObject("removed", "00000000000000ff"); err != nil {
+		t.Fatalf("could not index: %v", err)
+	}
+
+	if err := RemoveObject("removed"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := RemoveObject("never indexed"); err != nil {
+		t.Fatalf("unexpected error for an object never indexed: %v", err)
+	}
+
+	if hash, _ := GetObjectHash("removed"); hash != "" {
+		t.Errorf("hash of removed object = %q, want none", hash)
+	}
+	value, _ := ParseHash("00000000000000ff")
+	candidates, _ := index.GetBandHashes(getBandKeys(value))
+	if _, ok := candidates["removed"]; ok || len(candidates) != 1 {
+		t.Errorf("band candidates = %v, want only the kept object", candidates)
+	}
+}
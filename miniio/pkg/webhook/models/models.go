@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// Types of the events sent to the webhooks.
+const (
+	EventUploadStored  = "upload.stored"
+	EventUploadFailed  = "upload.failed"
+	EventUploadExpired = "upload.expired"
+)
+
+type Event struct {
+	EventId   string         `json:"event_id"`
+	EventType string         `json:"event_type"`
+	SessionId string         `json:"session_id"`
+	Timestamp time.Time      `json:"timestamp"`
+	Data      map[string]any `json:"data,omitempty"`
+}
@@ -0,0 +1,131 @@
+package webhook
+
+import (
+	webhook_models "ImageUploadMiniIo/pkg/webhook/models"
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Client used to deliver the events, with a timeout so that a slow webhook does not hold the delivery forever.
+var httpClient = &http.Client{Timeout: 10 * time.Second}
+
+// Function to get the webhook urls the events are sent to, from the comma separated environment variable.
+func getWebhookUrls() []string {
+	// Loading the environment variables.
+	var webhookUrls []string
+	for _, webhookUrl := range strings.Split(os.Getenv("WEBHOOK_URLS"), ",") {
+		webhookUrl = strings.TrimSpace(webhookUrl)
+		if webhookUrl != "" {
+			webhookUrls = append(webhookUrls, webhookUrl)
+		}
+	}
+
+	return webhookUrls
+}
+
+// Function to get the maximum number of times the delivery of an event is retried.
+func getMaxRetries() int {
+	// Loading the environment variables.
+	maxRetries, err := strconv.Atoi(os.Getenv("WEBHOOK_MAX_RETRIES"))
+	if err != nil || maxRetries < 0 {
+		return 3
+	}
+
+	return maxRetries
+}
+
+// Function to sign the body of an event with the webhook secret, using HMAC-SHA256.
+func Sign(body []byte, secret string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Function to send an event to every configured webhook in the background.
+// Nothing is sent if no webhook is configured.
+func Send(eventType string, sessionId string, data map[string]any) {
+	webhookUrls := getWebhookUrls()
+	if len(webhookUrls) == 0 {
+		return
+	}
+
+	var event webhook_models.Event
+	event.EventId = uuid.NewString()
+	event.EventType = eventType
+	event.SessionId = sessionId
+	event.Timestamp = time.Now()
+	event.Data = data
+
+	body, err := json.Marshal(event)
+	if err != nil {
+		log.Printf("Error: Could not serialise \"%s\" event of session id \"%s\": %s", eventType, sessionId, err.Error())
+		return
+	}
+
+	for _, webhookUrl := range webhookUrls {
+		go deliver(webhookUrl, &event, body)
+	}
+}
+
+// Function to deliver an event to a webhook, retrying with an exponential backoff until it is accepted.
+func deliver(webhookUrl string, event *webhook_models.Event, body []byte) {
+	maxRetries := getMaxRetries()
+	backoff := time.Second
+
+	for attempt := 0; attempt <= maxRetries; attempt++ {
+		if attempt > 0 {
+			time.Sleep(backoff)
+			backoff *= 2
+		}
+
+		err := post(webhookUrl, event, body)
+		if err == nil {
+			return
+		}
+
+		log.Printf("Error: Delivery of \"%s\" event to \"%s\" failed on attempt %d: %s", event.EventType, webhookUrl, attempt+1, err.Error())
+	}
+
+	log.Printf("Error: Giving up delivery of \"%s\" event with id \"%s\" to \"%s\".", event.EventType, event.EventId, webhookUrl)
+}
+
+// Function to post an event to a webhook once.
+func post(webhookUrl string, event *webhook_models.Event, body []byte) error {
+	request, err := http.NewRequest(http.MethodPost, webhookUrl, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("X-Webhook-Event", event.EventType)
+	request.Header.Set("X-Webhook-Id", event.EventId)
+	if secret := os.Getenv("WEBHOOK_SECRET"); secret != "" {
+		request.Header.Set("X-Webhook-Signature", Sign(body, secret))
+	}
+
+	response, err := httpClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	// Any status outside the success range is treated as a failed delivery.
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with status %d", response.StatusCode)
+	}
+
+	return nil
+}